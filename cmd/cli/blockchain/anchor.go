@@ -0,0 +1,74 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"blockchain/pkg/blockchain"
+	"blockchain/pkg/command"
+)
+
+var _ command.Cmd = (*anchorCmd)(nil)
+
+type anchorCmd struct {
+	From string `validate:"required"`
+	File string `validate:"required,file"`
+
+	baseCmd *cobra.Command
+}
+
+func (cmd *anchorCmd) GetCommand() *cobra.Command {
+	return cmd.baseCmd
+}
+
+func newAnchorCmd() command.Cmd {
+	cmd := &anchorCmd{}
+
+	baseCmd := &cobra.Command{
+		Use:   "anchor",
+		Short: "write the hash of a document into the blockchain",
+		RunE: func(_ *cobra.Command, args []string) error {
+			content, err := os.ReadFile(cmd.File)
+			if err != nil {
+				return err
+			}
+			docHash := sha256.Sum256(content)
+
+			chain, err := blockchain.ContinueBlockChain(chainOpts()...)
+			if err != nil {
+				return err
+			}
+			defer chain.Close()
+
+			utxo := blockchain.NewUTXOSet(chain)
+
+			tx, err := blockchain.NewDataTransaction(cmd.From, docHash[:], utxo)
+			if err != nil {
+				return err
+			}
+
+			cbTx, err := blockchain.CoinbaseTx(cmd.From, "")
+			if err != nil {
+				return err
+			}
+
+			block, err := chain.MineBlock([]*blockchain.Transaction{tx, cbTx})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Anchored %s (sha256 %x)\n", cmd.File, docHash)
+			fmt.Printf("Transaction: %x\n", tx.ID)
+			fmt.Printf("Block: %x (height %d)\n", block.Hash, block.Height)
+			return nil
+		},
+	}
+	baseCmd.Flags().StringVar(&cmd.From, "from", "", "wallet Address paying for the anchor")
+	baseCmd.Flags().StringVar(&cmd.File, "file", "", "document to anchor")
+
+	cmd.baseCmd = baseCmd
+	return cmd
+}
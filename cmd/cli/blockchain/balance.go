@@ -34,7 +34,7 @@ func newBalanceCmd() command.Cmd {
 				return err
 			}
 
-			chain, err := blockchain.ContinueBlockChain()
+			chain, err := blockchain.ContinueBlockChain(chainOpts()...)
 			if err != nil {
 				return err
 			}
@@ -6,14 +6,18 @@ import (
 	"github.com/spf13/cobra"
 
 	"blockchain/pkg/blockchain"
+	"blockchain/pkg/chaincfg"
 	"blockchain/pkg/command"
+	"blockchain/pkg/consensus/poa"
 	"blockchain/pkg/wallet"
 )
 
 var _ command.Cmd = (*createCmd)(nil)
 
 type createCmd struct {
-	Address string `validate:"required"` //btc address
+	Address string   `validate:"required"` //btc address
+	Network string   `validate:"required"`
+	Signers []string // initial signers of a proof of authority network
 
 	baseCmd *cobra.Command
 }
@@ -34,22 +38,37 @@ func newCreateCmd() command.Cmd {
 				return err
 			}
 
-			chain, err := blockchain.InitBlockChain(cmd.Address)
+			params, err := chaincfg.ParamsByName(cmd.Network)
 			if err != nil {
 				return err
 			}
-			defer chain.Close()
 
-			utxoSet := blockchain.NewUTXOSet(chain)
-			if err = utxoSet.Reindex(); err != nil {
+			opts := chainOpts(blockchain.WithParams(params))
+			if len(cmd.Signers) > 0 {
+				signers := make([][]byte, 0, len(cmd.Signers))
+				for _, address := range cmd.Signers {
+					pubKeyHash, err := wallet.PubKeyHashFromAddress(address)
+					if err != nil {
+						return fmt.Errorf("invalid signer address %s: %w", address, err)
+					}
+					signers = append(signers, pubKeyHash)
+				}
+				opts = append(opts, blockchain.WithGenesisExtra(poa.GenesisExtra(signers)))
+			}
+
+			chain, err := blockchain.InitBlockChain(cmd.Address, opts...)
+			if err != nil {
 				return err
 			}
+			defer chain.Close()
 
-			fmt.Println("Created a new blockchain")
+			fmt.Printf("Created a new %s blockchain\n", params.Name)
 			return nil
 		},
 	}
 	baseCmd.Flags().StringVar(&cmd.Address, "address", "", "genesis wallet Address")
+	baseCmd.Flags().StringVar(&cmd.Network, "network", chaincfg.DefaultParams.Name, "network parameters of the chain")
+	baseCmd.Flags().StringSliceVar(&cmd.Signers, "signer", nil, "wallet address of an initial signer, for proof of authority networks")
 
 	cmd.baseCmd = baseCmd
 	return cmd
@@ -0,0 +1,70 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"blockchain/pkg/blockchain"
+	"blockchain/pkg/command"
+	"blockchain/pkg/explorer"
+)
+
+var _ command.Cmd = (*explorerCmd)(nil)
+
+type explorerCmd struct {
+	Listen       string `validate:"required"`
+	AddressIndex bool
+
+	baseCmd *cobra.Command
+}
+
+func (cmd *explorerCmd) GetCommand() *cobra.Command {
+	return cmd.baseCmd
+}
+
+func newExplorerCmd() command.Cmd {
+	cmd := &explorerCmd{}
+
+	// the node serves the explorer of a running chain, with its mempool
+	baseCmd := &cobra.Command{
+		Use:   "explorer",
+		Short: "serves a web page to browse a blockchain no node is running on",
+		RunE: func(_ *cobra.Command, args []string) error {
+			var opts []blockchain.BlockChainOpt
+			if cmd.AddressIndex {
+				opts = append(opts, blockchain.WithAddressIndex())
+			}
+
+			chain, err := blockchain.ContinueBlockChain(chainOpts(opts...)...)
+			if err != nil {
+				return err
+			}
+			defer chain.Close()
+
+			srv, err := explorer.NewServer(chain)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			fmt.Printf("Explorer listening on http://%s\n", cmd.Listen)
+			if err := srv.ListenAndServe(ctx, cmd.Listen); err != nil && ctx.Err() == nil {
+				return err
+			}
+
+			return nil
+		},
+	}
+	baseCmd.Flags().StringVar(&cmd.Listen, "listen", "localhost:8080", "address to serve the explorer on")
+	baseCmd.Flags().BoolVar(&cmd.AddressIndex, "address-index", true, "build the address index to list the transactions of addresses")
+
+	cmd.baseCmd = baseCmd
+	return cmd
+}
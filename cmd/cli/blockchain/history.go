@@ -0,0 +1,113 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"blockchain/pkg/blockchain"
+	"blockchain/pkg/command"
+	"blockchain/pkg/wallet"
+)
+
+var _ command.Cmd = (*historyCmd)(nil)
+
+type historyCmd struct {
+	Address string `validate:"required"`
+	Offset  int    `validate:"gte=0"`
+	Limit   int    `validate:"gte=0"` // 0 lists every transaction
+	Format  string `validate:"oneof=table json"`
+
+	baseCmd *cobra.Command
+}
+
+func (cmd *historyCmd) GetCommand() *cobra.Command {
+	return cmd.baseCmd
+}
+
+// historyEntry is a blockchain.AddressTx as printed in json
+type historyEntry struct {
+	Height    int    `json:"height"`
+	TxID      string `json:"txid"`
+	Direction string `json:"direction"`
+	Amount    int    `json:"amount"`
+}
+
+func newHistoryCmd() command.Cmd {
+	cmd := &historyCmd{}
+
+	baseCmd := &cobra.Command{
+		Use:   "history",
+		Short: "lists the transactions of an address, newest first",
+		RunE: func(_ *cobra.Command, args []string) error {
+			pubKeyHash, err := wallet.PubKeyHashFromAddress(cmd.Address)
+			if err != nil {
+				return err
+			}
+
+			// the index is built on first use and kept up to date afterwards
+			chain, err := blockchain.ContinueBlockChain(chainOpts(blockchain.WithAddressIndex())...)
+			if err != nil {
+				return err
+			}
+			defer chain.Close()
+
+			txs, total, err := chain.History(pubKeyHash, cmd.Offset, cmd.Limit)
+			if err != nil {
+				return err
+			}
+
+			entries := make([]historyEntry, 0, len(txs))
+			for _, tx := range txs {
+				entries = append(entries, historyEntry{
+					Height:    tx.Height,
+					TxID:      hex.EncodeToString(tx.TxID),
+					Direction: tx.Direction.String(),
+					Amount:    tx.Amount,
+				})
+			}
+
+			if cmd.Format == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(struct {
+					Total        int            `json:"total"`
+					Offset       int            `json:"offset"`
+					Transactions []historyEntry `json:"transactions"`
+				}{total, cmd.Offset, entries})
+			}
+
+			return printHistory(entries, cmd.Offset, total)
+		},
+	}
+	baseCmd.Flags().StringVar(&cmd.Address, "address", "", "wallet address")
+	baseCmd.Flags().IntVar(&cmd.Offset, "offset", 0, "number of newer transactions to skip")
+	baseCmd.Flags().IntVar(&cmd.Limit, "limit", 20, "maximum number of transactions to list, 0 for all")
+	baseCmd.Flags().StringVar(&cmd.Format, "format", "table", "output format, table or json")
+
+	cmd.baseCmd = baseCmd
+	return cmd
+}
+
+func printHistory(entries []historyEntry, offset, total int) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "HEIGHT\tTXID\tDIRECTION\tAMOUNT")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\n", e.Height, e.TxID, e.Direction, e.Amount)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		fmt.Printf("\nNo transactions past %d of %d\n", offset, total)
+		return nil
+	}
+	fmt.Printf("\nTransactions %d to %d of %d\n", offset+1, offset+len(entries), total)
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package blockchain
+
+import (
+	"github.com/spf13/cobra"
+
+	"blockchain/pkg/blockchain"
+	"blockchain/pkg/command"
+	"blockchain/pkg/wallet"
+)
+
+var _ command.Cmd = (*htlcCmd)(nil)
+
+type htlcCmd struct {
+	baseCmd *cobra.Command
+}
+
+func (cmd *htlcCmd) GetCommand() *cobra.Command {
+	return cmd.baseCmd
+}
+
+func newHTLCCmd() command.Cmd {
+	cmd := &htlcCmd{}
+
+	baseCmd := &cobra.Command{
+		Use:   "htlc",
+		Short: "hash time-locked contracts for atomic swaps",
+	}
+
+	b := &command.Builder{}
+	b.AddCommand(
+		newHTLCCreateCmd(),
+		newHTLCClaimCmd(),
+		newHTLCRefundCmd(),
+		newHTLCSecretCmd(),
+	)
+	b.Build(baseCmd)
+
+	cmd.baseCmd = baseCmd
+	return cmd
+}
+
+// mineHTLCTransaction mines tx into a new block, rewarding the owner of its first output
+func mineHTLCTransaction(chain *blockchain.BlockChain, tx *blockchain.Transaction) error {
+	ownerHash := tx.Outputs[0].PubKeyHash
+	if tx.Outputs[0].Type == blockchain.OutputHTLC {
+		ownerHash = tx.Outputs[0].HTLC.Sender
+	}
+	owner, err := wallet.AddressFromPubKeyHash(ownerHash)
+	if err != nil {
+		return err
+	}
+
+	cbTx, err := blockchain.CoinbaseTx(owner, "")
+	if err != nil {
+		return err
+	}
+
+	_, err = chain.MineBlock([]*blockchain.Transaction{tx, cbTx})
+
+	return err
+}
@@ -0,0 +1,68 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"blockchain/pkg/blockchain"
+	"blockchain/pkg/command"
+)
+
+var _ command.Cmd = (*htlcClaimCmd)(nil)
+
+type htlcClaimCmd struct {
+	TxID     string `validate:"required,hexadecimal"`
+	Out      int    `validate:"gte=0"`
+	Preimage string `validate:"required,hexadecimal"`
+
+	baseCmd *cobra.Command
+}
+
+func (cmd *htlcClaimCmd) GetCommand() *cobra.Command {
+	return cmd.baseCmd
+}
+
+func newHTLCClaimCmd() command.Cmd {
+	cmd := &htlcClaimCmd{}
+
+	baseCmd := &cobra.Command{
+		Use:   "claim",
+		Short: "claim a htlc to its recipient by revealing the secret",
+		RunE: func(_ *cobra.Command, args []string) error {
+			txID, err := hex.DecodeString(cmd.TxID)
+			if err != nil {
+				return err
+			}
+			preimage, err := hex.DecodeString(cmd.Preimage)
+			if err != nil {
+				return err
+			}
+
+			chain, err := blockchain.ContinueBlockChain(chainOpts()...)
+			if err != nil {
+				return err
+			}
+			defer chain.Close()
+
+			tx, err := blockchain.NewHTLCClaimTransaction(txID, cmd.Out, preimage, blockchain.NewUTXOSet(chain))
+			if err != nil {
+				return err
+			}
+
+			if err := mineHTLCTransaction(chain, tx); err != nil {
+				return err
+			}
+
+			fmt.Printf("Claimed htlc %s:%d in transaction %x\n", cmd.TxID, cmd.Out, tx.ID)
+			return nil
+		},
+	}
+	baseCmd.Flags().StringVar(&cmd.TxID, "txid", "", "id of the transaction holding the htlc")
+	baseCmd.Flags().IntVar(&cmd.Out, "out", 0, "index of the htlc output")
+	baseCmd.Flags().StringVar(&cmd.Preimage, "preimage", "", "hex secret matching the secret hash")
+
+	cmd.baseCmd = baseCmd
+	return cmd
+}
@@ -0,0 +1,84 @@
+package blockchain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"blockchain/pkg/blockchain"
+	"blockchain/pkg/command"
+)
+
+var _ command.Cmd = (*htlcCreateCmd)(nil)
+
+type htlcCreateCmd struct {
+	From       string `validate:"required"`
+	To         string `validate:"required"`
+	Amount     int    `validate:"gt=0"`
+	SecretHash string `validate:"omitempty,hexadecimal,len=64"`
+	Timeout    int64  `validate:"gt=0"` // block height or unix time
+
+	baseCmd *cobra.Command
+}
+
+func (cmd *htlcCreateCmd) GetCommand() *cobra.Command {
+	return cmd.baseCmd
+}
+
+func newHTLCCreateCmd() command.Cmd {
+	cmd := &htlcCreateCmd{}
+
+	baseCmd := &cobra.Command{
+		Use:   "create",
+		Short: "lock amount in a htlc claimable by the recipient until the timeout",
+		RunE: func(_ *cobra.Command, args []string) error {
+			var secretHash []byte
+			if cmd.SecretHash == "" {
+				secret := make([]byte, sha256.Size)
+				if _, err := rand.Read(secret); err != nil {
+					return err
+				}
+				hash := sha256.Sum256(secret)
+				secretHash = hash[:]
+				fmt.Printf("Secret: %x\n", secret)
+			} else {
+				var err error
+				if secretHash, err = hex.DecodeString(cmd.SecretHash); err != nil {
+					return err
+				}
+			}
+
+			chain, err := blockchain.ContinueBlockChain(chainOpts()...)
+			if err != nil {
+				return err
+			}
+			defer chain.Close()
+
+			utxo := blockchain.NewUTXOSet(chain)
+
+			tx, err := blockchain.NewHTLCTransaction(cmd.From, cmd.To, cmd.Amount, secretHash, cmd.Timeout, utxo)
+			if err != nil {
+				return err
+			}
+
+			if err := mineHTLCTransaction(chain, tx); err != nil {
+				return err
+			}
+
+			fmt.Printf("Secret hash: %x\n", secretHash)
+			fmt.Printf("Locked %d in htlc %x:0\n", cmd.Amount, tx.ID)
+			return nil
+		},
+	}
+	baseCmd.Flags().StringVar(&cmd.From, "from", "", "sender wallet Address, able to refund after the timeout")
+	baseCmd.Flags().StringVar(&cmd.To, "to", "", "recipient wallet Address, able to claim with the secret")
+	baseCmd.Flags().IntVar(&cmd.Amount, "amount", 0, "amount to lock")
+	baseCmd.Flags().StringVar(&cmd.SecretHash, "secret-hash", "", "hex sha256 of the secret, a new secret is generated if empty")
+	baseCmd.Flags().Int64Var(&cmd.Timeout, "timeout", 0, "block height or unix time after which the sender can refund")
+
+	cmd.baseCmd = baseCmd
+	return cmd
+}
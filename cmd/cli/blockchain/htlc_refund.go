@@ -0,0 +1,62 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"blockchain/pkg/blockchain"
+	"blockchain/pkg/command"
+)
+
+var _ command.Cmd = (*htlcRefundCmd)(nil)
+
+type htlcRefundCmd struct {
+	TxID string `validate:"required,hexadecimal"`
+	Out  int    `validate:"gte=0"`
+
+	baseCmd *cobra.Command
+}
+
+func (cmd *htlcRefundCmd) GetCommand() *cobra.Command {
+	return cmd.baseCmd
+}
+
+func newHTLCRefundCmd() command.Cmd {
+	cmd := &htlcRefundCmd{}
+
+	baseCmd := &cobra.Command{
+		Use:   "refund",
+		Short: "refund a timed out htlc to its sender",
+		RunE: func(_ *cobra.Command, args []string) error {
+			txID, err := hex.DecodeString(cmd.TxID)
+			if err != nil {
+				return err
+			}
+
+			chain, err := blockchain.ContinueBlockChain(chainOpts()...)
+			if err != nil {
+				return err
+			}
+			defer chain.Close()
+
+			tx, err := blockchain.NewHTLCRefundTransaction(txID, cmd.Out, blockchain.NewUTXOSet(chain))
+			if err != nil {
+				return err
+			}
+
+			if err := mineHTLCTransaction(chain, tx); err != nil {
+				return err
+			}
+
+			fmt.Printf("Refunded htlc %s:%d in transaction %x\n", cmd.TxID, cmd.Out, tx.ID)
+			return nil
+		},
+	}
+	baseCmd.Flags().StringVar(&cmd.TxID, "txid", "", "id of the transaction holding the htlc")
+	baseCmd.Flags().IntVar(&cmd.Out, "out", 0, "index of the htlc output")
+
+	cmd.baseCmd = baseCmd
+	return cmd
+}
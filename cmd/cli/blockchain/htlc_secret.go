@@ -0,0 +1,58 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"blockchain/pkg/blockchain"
+	"blockchain/pkg/command"
+)
+
+var _ command.Cmd = (*htlcSecretCmd)(nil)
+
+type htlcSecretCmd struct {
+	TxID string `validate:"required,hexadecimal"`
+	Out  int    `validate:"gte=0"`
+
+	baseCmd *cobra.Command
+}
+
+func (cmd *htlcSecretCmd) GetCommand() *cobra.Command {
+	return cmd.baseCmd
+}
+
+func newHTLCSecretCmd() command.Cmd {
+	cmd := &htlcSecretCmd{}
+
+	baseCmd := &cobra.Command{
+		Use:   "secret",
+		Short: "print the secret revealed by the claim of a htlc",
+		RunE: func(_ *cobra.Command, args []string) error {
+			txID, err := hex.DecodeString(cmd.TxID)
+			if err != nil {
+				return err
+			}
+
+			chain, err := blockchain.ContinueBlockChain(chainOpts()...)
+			if err != nil {
+				return err
+			}
+			defer chain.Close()
+
+			secret, err := chain.FindHTLCSecret(txID, cmd.Out)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Secret: %x\n", secret)
+			return nil
+		},
+	}
+	baseCmd.Flags().StringVar(&cmd.TxID, "txid", "", "id of the transaction holding the htlc")
+	baseCmd.Flags().IntVar(&cmd.Out, "out", 0, "index of the htlc output")
+
+	cmd.baseCmd = baseCmd
+	return cmd
+}
@@ -27,26 +27,26 @@ func newPrintCmd() command.Cmd {
 		Use:   "print",
 		Short: "prints the blockchain",
 		RunE: func(_ *cobra.Command, args []string) error {
-			chain, err := blockchain.ContinueBlockChain()
+			chain, err := blockchain.ContinueBlockChain(chainOpts()...)
 			if err != nil {
 				return nil
 			}
 			defer chain.Close()
 
 			iter := chain.Iterator()
-			for iter.HasNext() {
-				block := iter.Next()
+			for iter.Next() {
+				block := iter.Block()
 				fmt.Printf("Prev. hash: %x\n", block.PrevHash)
 				fmt.Printf("Hash: %x\n", block.Hash)
-				pow := blockchain.NewProof(block)
-				fmt.Printf("PoW: %s\n", strconv.FormatBool(pow.Validate()))
+				sealErr := chain.Engine().VerifySeal(chain, &block.Header)
+				fmt.Printf("Seal: %s\n", strconv.FormatBool(sealErr == nil))
 				for _, tx := range block.Transactions {
 					fmt.Println(tx)
 				}
 				fmt.Println()
 			}
 
-			return nil
+			return iter.Err()
 		},
 	}
 
@@ -26,7 +26,7 @@ func newReindexCmd() command.Cmd {
 		Use:   "reindex",
 		Short: "reindex rebuilds the UTXO set",
 		RunE: func(_ *cobra.Command, args []string) error {
-			chain, err := blockchain.ContinueBlockChain()
+			chain, err := blockchain.ContinueBlockChain(chainOpts()...)
 			if err != nil {
 				return err
 			}
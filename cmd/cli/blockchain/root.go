@@ -3,6 +3,7 @@ package blockchain
 import (
 	"github.com/spf13/cobra"
 
+	"blockchain/pkg/blockchain"
 	"blockchain/pkg/command"
 )
 
@@ -11,7 +12,17 @@ var RootCmd = &cobra.Command{
 	Short: "simple blockchain implementation",
 }
 
+var (
+	// dataDir is the block database directory shared by all subcommands
+	dataDir string
+	// noAssumeValid checks the signatures of every block, see blockchain.WithoutAssumeValid
+	noAssumeValid bool
+)
+
 func init() {
+	RootCmd.PersistentFlags().StringVar(&dataDir, "datadir", blockchain.DefaultDataDir, "block database directory")
+	RootCmd.PersistentFlags().BoolVar(&noAssumeValid, "no-assume-valid", false, "check the signatures of every block, even below the assume valid block")
+
 	b := &command.Builder{}
 	b.AddCommand(
 		newCreateCmd(),
@@ -19,6 +30,24 @@ func init() {
 		newPrintCmd(),
 		newSendCmd(),
 		newReindexCmd(),
+		newHTLCCmd(),
+		newAnchorCmd(),
+		newVerifyAnchorCmd(),
+		newVoteCmd(),
+		newStatsCmd(),
+		newHistoryCmd(),
+		newExplorerCmd(),
 	)
 	b.Build(RootCmd)
 }
+
+// chainOpts returns the options of the persistent flags to open the chain
+// with, followed by opts
+func chainOpts(opts ...blockchain.BlockChainOpt) []blockchain.BlockChainOpt {
+	base := []blockchain.BlockChainOpt{blockchain.WithDataDir(dataDir)}
+	if noAssumeValid {
+		base = append(base, blockchain.WithoutAssumeValid())
+	}
+
+	return append(base, opts...)
+}
@@ -3,20 +3,28 @@ package blockchain
 import (
 	"errors"
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
 
 	"blockchain/pkg/blockchain"
 	"blockchain/pkg/command"
+	"blockchain/pkg/rpc"
 	"blockchain/pkg/wallet"
 )
 
 var _ command.Cmd = (*sendCmd)(nil)
 
 type sendCmd struct {
-	From   string `validate:"required"`
-	To     string `validate:"required"`
-	Amount int    `validate:"gte=0"`
+	From     string `validate:"required"`
+	To       string `validate:"required"`
+	Amount   int    `validate:"gte=0"`
+	LockTime int64  `validate:"gte=0"` // block height or unix time
+	// RPC is the address of a node to submit the transaction to instead of
+	// mining it into the local chain
+	RPC         string
+	RPCUser     string
+	RPCPassword string
 
 	baseCmd *cobra.Command
 }
@@ -39,7 +47,16 @@ func newSendCmd() command.Cmd {
 				return errors.New("invalid to address")
 			}
 
-			chain, err := blockchain.ContinueBlockChain()
+			if cmd.RPC != "" {
+				return cmd.submit()
+			}
+			// the block is mined right away, a lock time would only make
+			// the transaction invalid in it
+			if cmd.LockTime != 0 {
+				return errors.New("--locktime needs --rpc, the transaction has to wait in the mempool of a node")
+			}
+
+			chain, err := blockchain.ContinueBlockChain(chainOpts()...)
 			if err != nil {
 				return err
 			}
@@ -57,12 +74,7 @@ func newSendCmd() command.Cmd {
 				return err
 			}
 
-			block, err := chain.AddBlock([]*blockchain.Transaction{tx, cbTx})
-			if err != nil {
-				return err
-			}
-
-			if err := utxo.Update(block); err != nil {
+			if _, err := chain.MineBlock([]*blockchain.Transaction{tx, cbTx}); err != nil {
 				return err
 			}
 
@@ -73,7 +85,30 @@ func newSendCmd() command.Cmd {
 	baseCmd.Flags().StringVar(&cmd.From, "from", "", "source wallet Address")
 	baseCmd.Flags().StringVar(&cmd.To, "to", "", "destination wallet Address")
 	baseCmd.Flags().IntVar(&cmd.Amount, "amount", 0, "amount to send")
+	baseCmd.Flags().Int64Var(&cmd.LockTime, "locktime", 0, "block height or unix time before which the transaction is invalid, needs --rpc")
+	baseCmd.Flags().StringVar(&cmd.RPC, "rpc", os.Getenv("RPC_ADDR"), "JSON-RPC address of a node to send the transaction to, mined locally if empty")
+	baseCmd.Flags().StringVar(&cmd.RPCUser, "rpcuser", os.Getenv("RPC_USER"), "user of JSON-RPC requests")
+	baseCmd.Flags().StringVar(&cmd.RPCPassword, "rpcpassword", os.Getenv("RPC_PASSWORD"), "password of JSON-RPC requests")
 
 	cmd.baseCmd = baseCmd
 	return cmd
 }
+
+// submit funds the transaction from the UTXO set of the node at cmd.RPC and
+// adds it to its mempool, where it waits for its lock time
+func (cmd *sendCmd) submit() error {
+	client := rpc.NewClient(cmd.RPC, rpc.WithCredentials(cmd.RPCUser, cmd.RPCPassword))
+
+	tx, err := blockchain.NewTransaction(cmd.From, cmd.To, cmd.Amount, client, blockchain.WithLockTime(cmd.LockTime))
+	if err != nil {
+		return err
+	}
+
+	txID, err := client.SendTransaction(tx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Submitted %x sending %d from %s to %s\n", txID, cmd.Amount, cmd.From, cmd.To)
+	return nil
+}
@@ -0,0 +1,87 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"blockchain/pkg/blockchain"
+	"blockchain/pkg/command"
+)
+
+var _ command.Cmd = (*statsCmd)(nil)
+
+type statsCmd struct {
+	From   int    `validate:"gte=0"`
+	To     int    `validate:"gte=-1"` // -1 is the chain tip
+	Format string `validate:"oneof=table json"`
+
+	baseCmd *cobra.Command
+}
+
+func (cmd *statsCmd) GetCommand() *cobra.Command {
+	return cmd.baseCmd
+}
+
+func newStatsCmd() command.Cmd {
+	cmd := &statsCmd{}
+
+	baseCmd := &cobra.Command{
+		Use:   "stats",
+		Short: "prints statistics of the blockchain",
+		RunE: func(_ *cobra.Command, args []string) error {
+			chain, err := blockchain.ContinueBlockChain(chainOpts()...)
+			if err != nil {
+				return err
+			}
+			defer chain.Close()
+
+			stats, err := chain.Stats(cmd.From, cmd.To)
+			if err != nil {
+				return err
+			}
+
+			if cmd.Format == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(stats)
+			}
+
+			return printStats(stats)
+		},
+	}
+	baseCmd.Flags().IntVar(&cmd.From, "from", 0, "first block height")
+	baseCmd.Flags().IntVar(&cmd.To, "to", -1, "last block height, the chain tip by default")
+	baseCmd.Flags().StringVar(&cmd.Format, "format", "table", "output format, table or json")
+
+	cmd.baseCmd = baseCmd
+	return cmd
+}
+
+func printStats(stats *blockchain.Stats) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "Heights:\t%d to %d\n", stats.FromHeight, stats.ToHeight)
+	fmt.Fprintf(w, "Blocks:\t%d\n", stats.Blocks)
+	fmt.Fprintf(w, "Transactions:\t%d\n", stats.Transactions)
+	fmt.Fprintf(w, "Issued:\t%d\n", stats.Issued)
+	fmt.Fprintf(w, "Total supply:\t%d\n", stats.Supply)
+	fmt.Fprintf(w, "Avg. block interval:\t%.1fs\n", stats.AvgBlockInterval)
+	fmt.Fprintf(w, "UTXOs:\t%d\n", stats.UTXOs)
+	fmt.Fprintf(w, "UTXO value:\t%d\n", stats.UTXOValue)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FROM HEIGHT\tDIFFICULTY")
+	for _, d := range stats.Difficulty {
+		fmt.Fprintf(w, "%d\t%d\n", d.Height, d.Difficulty)
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,63 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"blockchain/pkg/blockchain"
+	"blockchain/pkg/command"
+)
+
+var _ command.Cmd = (*verifyAnchorCmd)(nil)
+
+type verifyAnchorCmd struct {
+	File string `validate:"required,file"`
+
+	baseCmd *cobra.Command
+}
+
+func (cmd *verifyAnchorCmd) GetCommand() *cobra.Command {
+	return cmd.baseCmd
+}
+
+func newVerifyAnchorCmd() command.Cmd {
+	cmd := &verifyAnchorCmd{}
+
+	baseCmd := &cobra.Command{
+		Use:   "verify-anchor",
+		Short: "find the block anchoring a document",
+		RunE: func(_ *cobra.Command, args []string) error {
+			content, err := os.ReadFile(cmd.File)
+			if err != nil {
+				return err
+			}
+			docHash := sha256.Sum256(content)
+
+			chain, err := blockchain.ContinueBlockChain(chainOpts()...)
+			if err != nil {
+				return err
+			}
+			defer chain.Close()
+
+			anchor, err := chain.FindAnchor(docHash[:])
+			if err != nil {
+				return fmt.Errorf("%s (sha256 %x): %w", cmd.File, docHash, err)
+			}
+
+			fmt.Printf("Document %s (sha256 %x) is anchored\n", cmd.File, docHash)
+			fmt.Printf("Transaction: %x\n", anchor.Transaction.ID)
+			fmt.Printf("Block: %x (height %d)\n", anchor.Block.Hash, anchor.Block.Height)
+			fmt.Printf("Timestamp: %s\n", time.Unix(anchor.Block.Timestamp, 0).UTC().Format(time.RFC3339))
+			fmt.Printf("Confirmations: %d\n", anchor.Confirmations)
+			return nil
+		},
+	}
+	baseCmd.Flags().StringVar(&cmd.File, "file", "", "document to look up")
+
+	cmd.baseCmd = baseCmd
+	return cmd
+}
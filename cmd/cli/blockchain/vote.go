@@ -0,0 +1,86 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"blockchain/pkg/blockchain"
+	"blockchain/pkg/command"
+	"blockchain/pkg/consensus/poa"
+	"blockchain/pkg/wallet"
+)
+
+var _ command.Cmd = (*voteCmd)(nil)
+
+type voteCmd struct {
+	Address string `validate:"required"` // receives the block reward
+	Target  string `validate:"required"`
+	Remove  bool
+
+	baseCmd *cobra.Command
+}
+
+func (cmd *voteCmd) GetCommand() *cobra.Command {
+	return cmd.baseCmd
+}
+
+func newVoteCmd() command.Cmd {
+	cmd := &voteCmd{}
+
+	baseCmd := &cobra.Command{
+		Use:   "vote",
+		Short: "seal a block voting to add or remove a signer of a proof of authority network",
+		RunE: func(_ *cobra.Command, args []string) error {
+			target, err := wallet.PubKeyHashFromAddress(cmd.Target)
+			if err != nil {
+				return errors.New("invalid target address")
+			}
+
+			chain, err := blockchain.ContinueBlockChain(chainOpts()...)
+			if err != nil {
+				return err
+			}
+			defer chain.Close()
+
+			engine, ok := chain.Engine().(*poa.ProofOfAuthority)
+			if !ok {
+				return fmt.Errorf("network %s has no signers to vote on", chain.Params().Name)
+			}
+
+			vote := poa.VoteAdd
+			if cmd.Remove {
+				vote = poa.VoteRemove
+			}
+			engine.Propose(target, vote)
+
+			cbTx, err := blockchain.CoinbaseTx(cmd.Address, "")
+			if err != nil {
+				return err
+			}
+
+			block, err := chain.MineBlock([]*blockchain.Transaction{cbTx})
+			if err != nil {
+				return err
+			}
+
+			signers, err := engine.Signers(chain, block.Hash)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Voted in block %d, the network has %d signers:\n", block.Height, len(signers))
+			for _, signer := range signers {
+				fmt.Printf("  %x\n", signer)
+			}
+			return nil
+		},
+	}
+	baseCmd.Flags().StringVar(&cmd.Address, "address", "", "wallet address receiving the block reward")
+	baseCmd.Flags().StringVar(&cmd.Target, "target", "", "wallet address of the signer voted on")
+	baseCmd.Flags().BoolVar(&cmd.Remove, "remove", false, "vote to remove the signer instead of adding it")
+
+	cmd.baseCmd = baseCmd
+	return cmd
+}
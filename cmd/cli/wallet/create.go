@@ -5,13 +5,18 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"blockchain/pkg/chaincfg"
 	"blockchain/pkg/command"
+	"blockchain/pkg/crypto"
 	"blockchain/pkg/wallet"
 )
 
 var _ command.Cmd = (*createCmd)(nil)
 
 type createCmd struct {
+	KeyType string `validate:"oneof=ecdsa ed25519 schnorr"`
+	Network string `validate:"required"`
+
 	baseCmd *cobra.Command
 }
 
@@ -26,15 +31,28 @@ func newCreateCmd() command.Cmd {
 		Use:   "create",
 		Short: "create a new wallet",
 		RunE: func(_ *cobra.Command, args []string) error {
-			address, err := wallet.CreateWallet()
+			keyType, err := crypto.ParseKeyType(cmd.KeyType)
+			if err != nil {
+				return err
+			}
+
+			params, err := chaincfg.ParamsByName(cmd.Network)
 			if err != nil {
 				return err
 			}
 
-			fmt.Printf("created wallet with address: %s\n", address)
+			address, err := wallet.CreateWallet(keyType, params.KeyOpts()...)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("created %s wallet with address: %s\n", keyType, address)
 			return nil
 		},
 	}
+	baseCmd.Flags().StringVar(&cmd.Network, "network", chaincfg.DefaultParams.Name, "network the wallet is used on, selects the ecdsa curve")
+	baseCmd.Flags().StringVar(&cmd.KeyType, "type", crypto.KeyECDSA.String(), "key type of the wallet: ecdsa, ed25519 or schnorr")
+
 	cmd.baseCmd = baseCmd
 
 	return cmd
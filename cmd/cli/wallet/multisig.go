@@ -0,0 +1,135 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"blockchain/pkg/blockchain"
+	"blockchain/pkg/command"
+	"blockchain/pkg/rpc"
+	"blockchain/pkg/wallet"
+)
+
+var _ command.Cmd = (*multisigCmd)(nil)
+
+type multisigCmd struct {
+	Required  int      `validate:"gt=0"`
+	Addresses []string `validate:"required,min=1"`
+
+	baseCmd *cobra.Command
+}
+
+func (cmd *multisigCmd) GetCommand() *cobra.Command {
+	return cmd.baseCmd
+}
+
+func newMultisigCmd() command.Cmd {
+	cmd := &multisigCmd{}
+
+	baseCmd := &cobra.Command{
+		Use:   "multisig",
+		Short: "create a M-of-N multisig address from local wallets, spend from one with the subcommands",
+		RunE: func(_ *cobra.Command, args []string) error {
+			pubKeys := make([][]byte, 0, len(cmd.Addresses))
+			for _, address := range cmd.Addresses {
+				w, err := wallet.GetWallet(address)
+				if err != nil {
+					return err
+				}
+				pubKeys = append(pubKeys, w.PublicKeyBytes())
+			}
+
+			script, err := wallet.NewMultisigScript(cmd.Required, pubKeys)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("created %d-of-%d multisig address: %s\n", cmd.Required, len(pubKeys), script.Address())
+			fmt.Printf("redeem script: %x\n", script.Serialize())
+			return nil
+		},
+	}
+	baseCmd.Flags().IntVar(&cmd.Required, "required", 0, "number of required signatures")
+	baseCmd.Flags().StringSliceVar(&cmd.Addresses, "address", nil, "wallet address of a cosigner")
+
+	b := &command.Builder{}
+	b.AddCommand(
+		newMultisigSpendCmd(),
+		newMultisigSignCmd(),
+		newMultisigCombineCmd(),
+	)
+	b.Build(baseCmd)
+
+	cmd.baseCmd = baseCmd
+	return cmd
+}
+
+// utxoSource is where multisig spends find the outputs they spend, the
+// local chain or a node over RPC
+type utxoSource struct {
+	dataDir             string
+	rpc, user, password string
+}
+
+func (src *utxoSource) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&src.dataDir, "datadir", blockchain.DefaultDataDir, "block database directory, unless --rpc is given")
+	src.addRPCFlags(cmd)
+}
+
+func (src *utxoSource) addRPCFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&src.rpc, "rpc", os.Getenv("RPC_ADDR"), "JSON-RPC address of a node to read the outputs from")
+	cmd.Flags().StringVar(&src.user, "rpcuser", os.Getenv("RPC_USER"), "user of JSON-RPC requests")
+	cmd.Flags().StringVar(&src.password, "rpcpassword", os.Getenv("RPC_PASSWORD"), "password of JSON-RPC requests")
+}
+
+// open returns the source and a func to release it
+func (src *utxoSource) open() (blockchain.UTXOSource, func(), error) {
+	if src.rpc != "" {
+		return src.client(), func() {}, nil
+	}
+
+	chain, err := blockchain.ContinueBlockChain(blockchain.WithDataDir(src.dataDir))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return blockchain.NewUTXOSet(chain), chain.Close, nil
+}
+
+func (src *utxoSource) client() *rpc.Client {
+	return rpc.NewClient(src.rpc, rpc.WithCredentials(src.user, src.password))
+}
+
+// readTx reads a transaction written by writeTx
+func readTx(path string) (*blockchain.Transaction, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%s does not hold a hex encoded transaction", path)
+	}
+
+	tx := &blockchain.Transaction{}
+	if err := tx.Deserialize(raw); err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// writeTx writes tx hex encoded, the form sendrawtransaction takes, so the
+// file can be passed between the cosigners
+func writeTx(path string, tx *blockchain.Transaction) error {
+	data, err := tx.Serialize()
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, []byte(hex.EncodeToString(data)+"\n"), 0644)
+}
@@ -0,0 +1,74 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"blockchain/pkg/command"
+)
+
+var _ command.Cmd = (*multisigCombineCmd)(nil)
+
+type multisigCombineCmd struct {
+	Txs []string `validate:"required,min=1"`
+	Out string
+
+	source  utxoSource
+	baseCmd *cobra.Command
+}
+
+func (cmd *multisigCombineCmd) GetCommand() *cobra.Command {
+	return cmd.baseCmd
+}
+
+func newMultisigCombineCmd() command.Cmd {
+	cmd := &multisigCombineCmd{}
+
+	baseCmd := &cobra.Command{
+		Use:   "combine",
+		Short: "merge the signatures of copies of a multisig spend and send it to a node",
+		RunE: func(_ *cobra.Command, args []string) error {
+			if cmd.Out == "" && cmd.source.rpc == "" {
+				return errors.New("give --out, --rpc or both")
+			}
+
+			tx, err := readTx(cmd.Txs[0])
+			if err != nil {
+				return err
+			}
+			for _, path := range cmd.Txs[1:] {
+				other, err := readTx(path)
+				if err != nil {
+					return err
+				}
+				if err := tx.CombineSignatures(other); err != nil {
+					return fmt.Errorf("%s: %w", path, err)
+				}
+			}
+
+			if cmd.Out != "" {
+				if err := writeTx(cmd.Out, tx); err != nil {
+					return err
+				}
+				fmt.Printf("Wrote transaction %x to %s\n", tx.ID, cmd.Out)
+			}
+
+			if cmd.source.rpc != "" {
+				txID, err := cmd.source.client().SendTransaction(tx)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Submitted transaction %x\n", txID)
+			}
+			return nil
+		},
+	}
+	baseCmd.Flags().StringSliceVar(&cmd.Txs, "tx", nil, "file of a signed copy of the transaction")
+	baseCmd.Flags().StringVar(&cmd.Out, "out", "", "file to write the combined transaction to")
+	cmd.source.addRPCFlags(baseCmd)
+
+	cmd.baseCmd = baseCmd
+	return cmd
+}
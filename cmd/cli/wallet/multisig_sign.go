@@ -0,0 +1,70 @@
+package wallet
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"blockchain/pkg/blockchain"
+	"blockchain/pkg/command"
+	"blockchain/pkg/wallet"
+)
+
+var _ command.Cmd = (*multisigSignCmd)(nil)
+
+type multisigSignCmd struct {
+	Tx      string `validate:"required"`
+	Address string `validate:"required"`
+
+	source  utxoSource
+	baseCmd *cobra.Command
+}
+
+func (cmd *multisigSignCmd) GetCommand() *cobra.Command {
+	return cmd.baseCmd
+}
+
+func newMultisigSignCmd() command.Cmd {
+	cmd := &multisigSignCmd{}
+
+	baseCmd := &cobra.Command{
+		Use:   "sign",
+		Short: "add the signature of a local wallet to a multisig spend file",
+		RunE: func(_ *cobra.Command, args []string) error {
+			w, err := wallet.GetWallet(cmd.Address)
+			if err != nil {
+				return err
+			}
+			tx, err := readTx(cmd.Tx)
+			if err != nil {
+				return err
+			}
+
+			utxo, closeSource, err := cmd.source.open()
+			if err != nil {
+				return err
+			}
+			defer closeSource()
+
+			prevTXs, err := blockchain.FindPrevTransactions(tx, utxo)
+			if err != nil {
+				return err
+			}
+			if err := tx.Sign(w.PrivateKey, prevTXs); err != nil {
+				return err
+			}
+			if err := writeTx(cmd.Tx, tx); err != nil {
+				return err
+			}
+
+			fmt.Printf("Signed transaction %x in %s with %s\n", tx.ID, cmd.Tx, cmd.Address)
+			return nil
+		},
+	}
+	baseCmd.Flags().StringVar(&cmd.Tx, "tx", "", "file of the transaction, signed in place")
+	baseCmd.Flags().StringVar(&cmd.Address, "address", "", "wallet address of the cosigner")
+	cmd.source.addFlags(baseCmd)
+
+	cmd.baseCmd = baseCmd
+	return cmd
+}
@@ -0,0 +1,79 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"blockchain/pkg/blockchain"
+	"blockchain/pkg/command"
+	"blockchain/pkg/wallet"
+)
+
+var _ command.Cmd = (*multisigSpendCmd)(nil)
+
+type multisigSpendCmd struct {
+	Script   string `validate:"required,hexadecimal"` // redeem script
+	To       string `validate:"required"`
+	Amount   int    `validate:"gt=0"`
+	LockTime int64  `validate:"gte=0"`
+	Out      string `validate:"required"`
+
+	source  utxoSource
+	baseCmd *cobra.Command
+}
+
+func (cmd *multisigSpendCmd) GetCommand() *cobra.Command {
+	return cmd.baseCmd
+}
+
+func newMultisigSpendCmd() command.Cmd {
+	cmd := &multisigSpendCmd{}
+
+	baseCmd := &cobra.Command{
+		Use:   "spend",
+		Short: "write an unsigned spend of the outputs of a multisig address to a file for the cosigners",
+		RunE: func(_ *cobra.Command, args []string) error {
+			redeem, err := hex.DecodeString(cmd.Script)
+			if err != nil {
+				return err
+			}
+			script, err := wallet.ParseMultisigScript(redeem)
+			if err != nil {
+				return err
+			}
+			if _, err := wallet.PubKeyHashFromAddress(cmd.To); err != nil {
+				return errors.New("invalid to address")
+			}
+
+			utxo, closeSource, err := cmd.source.open()
+			if err != nil {
+				return err
+			}
+			defer closeSource()
+
+			tx, err := blockchain.NewMultisigTransaction(script, cmd.To, cmd.Amount, utxo, blockchain.WithLockTime(cmd.LockTime))
+			if err != nil {
+				return err
+			}
+			if err := writeTx(cmd.Out, tx); err != nil {
+				return err
+			}
+
+			fmt.Printf("Wrote transaction %x sending %d from %s to %s to %s, it needs %d signatures\n",
+				tx.ID, cmd.Amount, script.Address(), cmd.To, cmd.Out, script.Required)
+			return nil
+		},
+	}
+	baseCmd.Flags().StringVar(&cmd.Script, "script", "", "hex redeem script of the multisig address")
+	baseCmd.Flags().StringVar(&cmd.To, "to", "", "destination wallet address")
+	baseCmd.Flags().IntVar(&cmd.Amount, "amount", 0, "amount to send")
+	baseCmd.Flags().Int64Var(&cmd.LockTime, "locktime", 0, "block height or unix time before which the transaction is invalid")
+	baseCmd.Flags().StringVar(&cmd.Out, "out", "", "file to write the transaction to")
+	cmd.source.addFlags(baseCmd)
+
+	cmd.baseCmd = baseCmd
+	return cmd
+}
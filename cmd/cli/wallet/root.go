@@ -16,6 +16,7 @@ func init() {
 	b.AddCommand(
 		newCreateCmd(),
 		newListCmd(),
+		newMultisigCmd(),
 	)
 	b.Build(RootCmd)
 }
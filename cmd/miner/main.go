@@ -1,22 +1,86 @@
-package miner
+package main
 
 import (
+	"context"
+	"flag"
+	"log"
 	"os"
 
 	"blockchain/pkg/blockchain"
+	"blockchain/pkg/explorer"
 	"blockchain/pkg/miner"
+	"blockchain/pkg/rpc"
+	"blockchain/pkg/stratum"
 )
 
 func main() {
-	chain, err := blockchain.ContinueBlockChain()
+	var (
+		walletAddr    = flag.String("wallet", os.Getenv("WALLET_ADDR"), "wallet address block rewards are paid to")
+		fullNodeAddr  = flag.String("fullnode", os.Getenv("FULL_NODE_ADDR"), "multiaddress of the full node to connect to")
+		port          = flag.Int("port", 1234, "port to listen for peers on")
+		dataDir       = flag.String("datadir", blockchain.DefaultDataDir, "block database directory")
+		noAssumeValid = flag.Bool("no-assume-valid", false, "check the signatures of every block, even below the assume valid block")
+		addrIndex     = flag.Bool("addrindex", false, "maintain an index of the transactions of every address, built on start if missing")
+		rpcAddr       = flag.String("rpc", os.Getenv("RPC_ADDR"), "address to serve JSON-RPC on, none by default")
+		rpcUser       = flag.String("rpcuser", os.Getenv("RPC_USER"), "user of JSON-RPC requests, required unless -rpc is a loopback address")
+		rpcPassword   = flag.String("rpcpassword", os.Getenv("RPC_PASSWORD"), "password of JSON-RPC requests")
+		stratumAddr   = flag.String("stratum", os.Getenv("STRATUM_ADDR"), "address to run a Stratum mining pool on, none by default")
+		shareDiff     = flag.Int("sharedifficulty", stratum.DefaultShareDifficulty, "difficulty of pool shares, in leading zero bits")
+		explorerAddr  = flag.String("explorer", os.Getenv("EXPLORER_ADDR"), "address to serve the block explorer on, none by default")
+	)
+	flag.Parse()
+
+	opts := []blockchain.BlockChainOpt{blockchain.WithDataDir(*dataDir)}
+	if *noAssumeValid {
+		opts = append(opts, blockchain.WithoutAssumeValid())
+	}
+	if *addrIndex {
+		opts = append(opts, blockchain.WithAddressIndex())
+	}
+	chain, err := blockchain.ContinueBlockChain(opts...)
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
 	}
 
-	walletAddr := os.Getenv("WALLET_ADDR")
-	nodeAddr := os.Getenv("NODE_ADDR")
-	fullNodeAddr := os.Getenv("FULL_NODE_ADDR")
+	m := miner.NewMiner(*walletAddr, *fullNodeAddr, *port, chain)
+
+	if *rpcAddr != "" {
+		opts := []rpc.ServerOpt{rpc.WithMempool(m.Pool()), rpc.WithNode(m)}
+		if *rpcUser != "" || *rpcPassword != "" {
+			opts = append(opts, rpc.WithBasicAuth(*rpcUser, *rpcPassword))
+		}
+		srv := rpc.NewServer(chain, opts...)
+		go func() {
+			if err := srv.ListenAndServe(context.Background(), *rpcAddr); err != nil {
+				log.Printf("rpc server stopped: %s", err)
+			}
+		}()
+	}
+
+	if *stratumAddr != "" {
+		// blocks found before any share pay the wallet of the miner
+		pool, err := stratum.NewServer(m, *walletAddr, stratum.WithShareDifficulty(*shareDiff))
+		if err != nil {
+			log.Fatal(err)
+		}
+		go func() {
+			if err := pool.ListenAndServe(context.Background(), *stratumAddr); err != nil {
+				log.Printf("stratum server stopped: %s", err)
+			}
+		}()
+	}
+
+	if *explorerAddr != "" {
+		srv, err := explorer.NewServer(chain, explorer.WithMempool(m.Pool()))
+		if err != nil {
+			log.Fatal(err)
+		}
+		go func() {
+			if err := srv.ListenAndServe(context.Background(), *explorerAddr); err != nil {
+				log.Printf("explorer stopped: %s", err)
+			}
+		}()
+	}
 
-	m := miner.NewMiner(walletAddr, nodeAddr, fullNodeAddr, chain)
 	m.Start()
 }
@@ -0,0 +1,269 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/dgraph-io/badger"
+)
+
+var (
+	// addrPrefix keys the address index, one entry per address, transaction
+	// and direction: prefix | len(pubKeyHash) | pubKeyHash | height | txid | direction
+	addrPrefix = []byte("addr-")
+	// addrTipKey holds the hash of the block the address index is built up to
+	addrTipKey = []byte("addrindex-tip")
+)
+
+// TxDirection tells whether a transaction paid an address or spent from it
+type TxDirection byte
+
+const (
+	TxReceived TxDirection = iota
+	TxSent
+)
+
+func (d TxDirection) String() string {
+	if d == TxSent {
+		return "sent"
+	}
+
+	return "received"
+}
+
+// AddressTx is a transaction touching an address. Amount is the value of
+// the outputs paying the address, or of the outputs of the address spent.
+type AddressTx struct {
+	Height    int
+	TxID      []byte
+	Direction TxDirection
+	Amount    int
+}
+
+type addressEntry struct {
+	pubKeyHash []byte
+	AddressTx
+}
+
+func addressKeyPrefix(pubKeyHash []byte) []byte {
+	key := append([]byte{}, addrPrefix...)
+	key = append(key, byte(len(pubKeyHash)))
+
+	return append(key, pubKeyHash...)
+}
+
+func (e *addressEntry) key() []byte {
+	key := binary.BigEndian.AppendUint64(addressKeyPrefix(e.pubKeyHash), uint64(e.Height))
+	key = append(key, e.TxID...)
+
+	return append(key, byte(e.Direction))
+}
+
+// History returns the transactions touching the address with the given
+// public key hash, newest first, skipping offset of them and returning up
+// to limit, all of them if limit is 0. total is the number of transactions
+// recorded for the address. The chain must be opened WithAddressIndex.
+func (bc *BlockChain) History(pubKeyHash []byte, offset, limit int) (txs []AddressTx, total int, err error) {
+	if !bc.addrIndex {
+		return nil, 0, ErrorAddrIndexDisabled
+	}
+
+	prefix := addressKeyPrefix(pubKeyHash)
+
+	err = bc.database.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Reverse = true
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		// heights are far below 0xff << 56, so this seeks past the last entry
+		for it.Seek(append(prefix, 0xff)); it.ValidForPrefix(prefix); it.Next() {
+			total++
+			if total <= offset || (limit > 0 && len(txs) == limit) {
+				continue
+			}
+
+			key := it.Item().Key()[len(prefix):]
+			val, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			txs = append(txs, AddressTx{
+				Height:    int(binary.BigEndian.Uint64(key[:8])),
+				TxID:      append([]byte{}, key[8:len(key)-1]...),
+				Direction: TxDirection(key[len(key)-1]),
+				Amount:    int(binary.BigEndian.Uint64(val)),
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("error while reading address index: %w", err)
+	}
+
+	return txs, total, nil
+}
+
+// addressEntries returns the address index entries of block, prevOut
+// returns the output an input spends
+func addressEntries(block *Block, prevOut func(in *TxInput) (*TxOutput, error)) ([]addressEntry, error) {
+	var entries []addressEntry
+
+	for _, tx := range block.Transactions {
+		add := func(amounts map[string]int, order [][]byte, direction TxDirection) {
+			for _, pubKeyHash := range order {
+				entries = append(entries, addressEntry{
+					pubKeyHash: pubKeyHash,
+					AddressTx: AddressTx{
+						Height:    block.Height,
+						TxID:      tx.ID,
+						Direction: direction,
+						Amount:    amounts[string(pubKeyHash)],
+					},
+				})
+			}
+		}
+
+		received, receivers := make(map[string]int), [][]byte(nil)
+		for _, out := range tx.Outputs {
+			if len(out.PubKeyHash) == 0 {
+				continue
+			}
+			if _, ok := received[string(out.PubKeyHash)]; !ok {
+				receivers = append(receivers, out.PubKeyHash)
+			}
+			received[string(out.PubKeyHash)] += out.Value
+		}
+		add(received, receivers, TxReceived)
+
+		if tx.IsCoinbase() {
+			continue
+		}
+
+		sent, senders := make(map[string]int), [][]byte(nil)
+		for i := range tx.Inputs {
+			out, err := prevOut(&tx.Inputs[i])
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := sent[string(out.PubKeyHash)]; !ok {
+				senders = append(senders, out.PubKeyHash)
+			}
+			sent[string(out.PubKeyHash)] += out.Value
+		}
+		add(sent, senders, TxSent)
+	}
+
+	return entries, nil
+}
+
+// blockAddressEntries returns the address index entries of block. The
+// outputs it spends are looked up in the UTXO set of txn, which must not
+// include block yet, or among the outputs of block itself.
+func blockAddressEntries(txn *badger.Txn, block *Block) ([]addressEntry, error) {
+	blockTXs := make(map[string]*Transaction, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		blockTXs[hex.EncodeToString(tx.ID)] = tx
+	}
+
+	return addressEntries(block, func(in *TxInput) (*TxOutput, error) {
+		if tx, ok := blockTXs[hex.EncodeToString(in.ID)]; ok && in.Out >= 0 && in.Out < len(tx.Outputs) {
+			return &tx.Outputs[in.Out], nil
+		}
+
+		outs, err := unspentOutputs(txn, in.ID)
+		if err != nil {
+			return nil, err
+		}
+		out, ok := outs[in.Out]
+		if !ok {
+			return nil, fmt.Errorf("%w: output %x-%d", ErrorTxNotFound, in.ID, in.Out)
+		}
+
+		return &out, nil
+	})
+}
+
+// putAddressEntries records entries in the address index, or removes them
+// when the block they belong to is disconnected, and moves the index tip
+func putAddressEntries(txn *badger.Txn, entries []addressEntry, tip []byte, remove bool) error {
+	for i := range entries {
+		var err error
+		if remove {
+			err = txn.Delete(entries[i].key())
+		} else {
+			err = txn.Set(entries[i].key(), binary.BigEndian.AppendUint64(nil, uint64(entries[i].Amount)))
+		}
+		if err != nil {
+			return fmt.Errorf("error while updating address index: %w", err)
+		}
+	}
+
+	return txn.Set(addrTipKey, tip)
+}
+
+// syncAddressIndex rebuilds the address index if it was not kept up to
+// date with the main chain, by a run without WithAddressIndex for instance
+func (bc *BlockChain) syncAddressIndex() error {
+	var tip []byte
+	err := bc.database.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(addrTipKey)
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		tip, err = item.ValueCopy(nil)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(tip, bc.LastHash()) {
+		return nil
+	}
+
+	if err := NewUTXOSet(bc).DeleteByPrefix(addrPrefix); err != nil {
+		return err
+	}
+
+	// outputs holds the unspent outputs seen so far, by transaction id and index
+	outputs := make(map[string]*TxOutput)
+	iter := bc.Iterator(WithDirection(Forward))
+	for iter.Next() {
+		block := iter.Block()
+		for _, tx := range block.Transactions {
+			for i := range tx.Outputs {
+				outputs[fmt.Sprintf("%x-%d", tx.ID, i)] = &tx.Outputs[i]
+			}
+		}
+
+		entries, err := addressEntries(block, func(in *TxInput) (*TxOutput, error) {
+			key := fmt.Sprintf("%x-%d", in.ID, in.Out)
+			out, ok := outputs[key]
+			if !ok {
+				return nil, fmt.Errorf("%w: output %x-%d", ErrorTxNotFound, in.ID, in.Out)
+			}
+			delete(outputs, key)
+
+			return out, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := bc.database.Update(func(txn *badger.Txn) error {
+			return putAddressEntries(txn, entries, block.Hash, false)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return iter.Err()
+}
@@ -0,0 +1,77 @@
+package blockchain
+
+import (
+	"bytes"
+	"testing"
+
+	"blockchain/pkg/wallet"
+)
+
+func TestAddressIndex(t *testing.T) {
+	alice, bob := newTestWallet(t), newTestWallet(t)
+	chain, err := InitBlockChain(alice, WithDataDir(t.TempDir()), WithAddressIndex())
+	if err != nil {
+		t.Fatalf("InitBlockChain: %v", err)
+	}
+	t.Cleanup(chain.Close)
+
+	genesis, err := chain.GetBlock(chain.LastHash())
+	if err != nil {
+		t.Fatalf("GetBlock: %v", err)
+	}
+	tx, err := NewTransaction(alice, bob, 5, NewUTXOSet(chain))
+	if err != nil {
+		t.Fatalf("NewTransaction: %v", err)
+	}
+	block := mineTestBlock(t, chain, bob, tx)
+
+	for _, tc := range []struct {
+		name    string
+		address string
+		want    []AddressTx
+	}{
+		{
+			name:    "alice",
+			address: alice,
+			want: []AddressTx{
+				{Height: 1, TxID: tx.ID, Direction: TxSent, Amount: minerReward},
+				{Height: 1, TxID: tx.ID, Direction: TxReceived, Amount: minerReward - 5},
+				{Height: 0, TxID: genesis.Transactions[0].ID, Direction: TxReceived, Amount: minerReward},
+			},
+		},
+		{
+			name:    "bob",
+			address: bob,
+			want: []AddressTx{
+				{Height: 1, TxID: block.Transactions[1].ID, Direction: TxReceived, Amount: minerReward},
+				{Height: 1, TxID: tx.ID, Direction: TxReceived, Amount: 5},
+			},
+		},
+	} {
+		pubKeyHash, err := wallet.PubKeyHashFromAddress(tc.address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		history, total, err := chain.History(pubKeyHash, 0, 0)
+		if err != nil {
+			t.Fatalf("History: %v", err)
+		}
+		if total != len(tc.want) {
+			t.Errorf("%s: %d transactions recorded, want %d", tc.name, total, len(tc.want))
+		}
+
+		// entries of one height are ordered by transaction id
+		if tc.name == "bob" && bytes.Compare(tx.ID, block.Transactions[1].ID) > 0 {
+			tc.want[0], tc.want[1] = tc.want[1], tc.want[0]
+		}
+		if len(history) != len(tc.want) {
+			t.Fatalf("%s: history %+v, want %+v", tc.name, history, tc.want)
+		}
+		for i := range history {
+			got, want := history[i], tc.want[i]
+			if got.Height != want.Height || !bytes.Equal(got.TxID, want.TxID) || got.Direction != want.Direction || got.Amount != want.Amount {
+				t.Errorf("%s: entry %d is %+v, want %+v", tc.name, i, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package blockchain
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Anchor locates a data output in the chain
+type Anchor struct {
+	Block         *Block
+	Transaction   *Transaction
+	Confirmations int
+}
+
+// NewDataTransaction writes data into an unspendable output paid for by the from wallet
+func NewDataTransaction(from string, data []byte, utxo *UTXOSet, opts ...TxOpt) (*Transaction, error) {
+	out, err := NewDataOutput(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrorTxCreateFailed, err)
+	}
+
+	return fundTransaction(from, out, utxo, opts...)
+}
+
+// FindAnchor returns the earliest data output carrying data
+func (bc *BlockChain) FindAnchor(data []byte) (*Anchor, error) {
+	height, err := bc.GetBaseHeight()
+	if err != nil {
+		return nil, err
+	}
+
+	var anchor *Anchor
+
+	iter := bc.Iterator()
+	for iter.Next() {
+		block := iter.Block()
+		for _, tx := range block.Transactions {
+			for _, out := range tx.Outputs {
+				if out.Type == OutputData && bytes.Equal(out.Data, data) {
+					anchor = &Anchor{
+						Block:         block,
+						Transaction:   tx,
+						Confirmations: height - block.Height + 1,
+					}
+				}
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("error while iterating blocks: %w", err)
+	}
+
+	if anchor == nil {
+		return nil, ErrorAnchorNotFound
+	}
+
+	return anchor, nil
+}
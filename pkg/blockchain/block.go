@@ -1,37 +1,37 @@
 package blockchain
 
 import (
+	"bytes"
+	"fmt"
 	"time"
 
+	"blockchain/pkg/consensus"
 	"blockchain/pkg/util"
 )
 
 type Block struct {
-	Timestamp    int64
+	consensus.Header
 	Transactions []*Transaction
-	Hash         []byte
-	PrevHash     []byte
-	Nonce        int
-	Height       int
 }
 
-func CreateBlock(txs []*Transaction, prevHash []byte, height int) *Block {
+// NewBlock assembles an unsealed block, BlockChain.SealBlock completes its header
+func NewBlock(txs []*Transaction, prevHash []byte, height int) *Block {
 	block := &Block{
-		Timestamp:    time.Now().Unix(),
+		Header: consensus.Header{
+			Timestamp: time.Now().Unix(),
+			PrevHash:  prevHash,
+			Height:    height,
+		},
 		Transactions: txs,
-		PrevHash:     prevHash,
-		Height:       height,
 	}
-	pow := NewProof(block)
-	nonce, hash := pow.Run()
-	block.Hash = hash
-	block.Nonce = nonce
+	block.TxRoot = block.HashTransactions()
+	block.WitnessRoot = block.HashWitnesses()
 
 	return block
 }
 
 func Genesis(coinbase *Transaction) *Block {
-	return CreateBlock([]*Transaction{coinbase}, []byte{}, 0)
+	return NewBlock([]*Transaction{coinbase}, []byte{}, 0)
 }
 
 func (b *Block) HashTransactions() []byte {
@@ -44,10 +44,78 @@ func (b *Block) HashTransactions() []byte {
 	return tree.RootNode.Data
 }
 
+// HashWitnesses commits to the transactions including their witnesses
+func (b *Block) HashWitnesses() []byte {
+	var txHashes [][]byte
+	for _, tx := range b.Transactions {
+		txHashes = append(txHashes, tx.WitnessHash())
+	}
+	tree := NewMerkleTree(txHashes)
+
+	return tree.RootNode.Data
+}
+
+// verifyCommitments checks that the transaction ids and the roots of the
+// header match the transactions carried by the block
+func (b *Block) verifyCommitments() error {
+	for _, tx := range b.Transactions {
+		if !bytes.Equal(tx.ID, tx.Hash()) {
+			return fmt.Errorf("%w: id of transaction %x does not match its contents", ErrorTxInvalid, tx.ID)
+		}
+	}
+
+	if !bytes.Equal(b.TxRoot, b.HashTransactions()) {
+		return ErrorBlkTxRootInvalid
+	}
+	if !bytes.Equal(b.WitnessRoot, b.HashWitnesses()) {
+		return ErrorBlkWitnessInvalid
+	}
+
+	return nil
+}
+
 func (b *Block) Serialize() ([]byte, error) {
 	return util.GobEncode(b)
 }
 
+// legacyDifficulty is the proof of work difficulty every block had before
+// difficulty was part of the header
+const legacyDifficulty = 12
+
+// storedBlock decodes blocks of either layout. Before the consensus header
+// the header fields were stored at the top level of the block, gob leaves
+// them out of a Block without an error.
+type storedBlock struct {
+	Header       consensus.Header
+	Transactions []*Transaction
+
+	Timestamp   int64
+	Hash        []byte
+	PrevHash    []byte
+	WitnessRoot []byte
+	Nonce       int
+	Height      int
+}
+
 func (b *Block) Deserialize(data []byte) error {
-	return util.GobDecode(data, b)
+	var stored storedBlock
+	if err := util.GobDecode(data, &stored); err != nil {
+		return err
+	}
+
+	b.Header, b.Transactions = stored.Header, stored.Transactions
+	if b.Hash == nil && stored.Hash != nil {
+		b.Header = consensus.Header{
+			Timestamp:   stored.Timestamp,
+			Hash:        stored.Hash,
+			PrevHash:    stored.PrevHash,
+			TxRoot:      b.HashTransactions(),
+			WitnessRoot: stored.WitnessRoot,
+			Height:      stored.Height,
+			Difficulty:  legacyDifficulty,
+			Nonce:       stored.Nonce,
+		}
+	}
+
+	return nil
 }
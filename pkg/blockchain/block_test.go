@@ -0,0 +1,73 @@
+package blockchain
+
+import (
+	"bytes"
+	"testing"
+
+	"blockchain/pkg/util"
+)
+
+func TestDeserializeLegacyBlock(t *testing.T) {
+	alice := newTestWallet(t)
+	cbTx, err := CoinbaseTx(alice, "")
+	if err != nil {
+		t.Fatalf("CoinbaseTx: %v", err)
+	}
+
+	// the layout blocks were stored with before the consensus header
+	legacy := struct {
+		Timestamp    int64
+		Transactions []*Transaction
+		Hash         []byte
+		PrevHash     []byte
+		WitnessRoot  []byte
+		Nonce        int
+		Height       int
+	}{
+		Timestamp:    1700000000,
+		Transactions: []*Transaction{cbTx},
+		Hash:         []byte("block hash"),
+		PrevHash:     []byte("previous block hash"),
+		WitnessRoot:  []byte("witness root"),
+		Nonce:        42,
+		Height:       7,
+	}
+	data, err := util.GobEncode(&legacy)
+	if err != nil {
+		t.Fatalf("GobEncode: %v", err)
+	}
+
+	block := &Block{}
+	if err := block.Deserialize(data); err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+
+	if block.Timestamp != legacy.Timestamp || block.Nonce != legacy.Nonce || block.Height != legacy.Height {
+		t.Errorf("header %+v does not match the legacy block", block.Header)
+	}
+	if !bytes.Equal(block.Hash, legacy.Hash) || !bytes.Equal(block.PrevHash, legacy.PrevHash) || !bytes.Equal(block.WitnessRoot, legacy.WitnessRoot) {
+		t.Errorf("header %+v does not match the legacy block", block.Header)
+	}
+	if block.Difficulty != legacyDifficulty {
+		t.Errorf("difficulty = %d, want %d", block.Difficulty, legacyDifficulty)
+	}
+	if !bytes.Equal(block.TxRoot, block.HashTransactions()) {
+		t.Error("transaction root does not match the transactions")
+	}
+	if len(block.Transactions) != 1 || !bytes.Equal(block.Transactions[0].ID, cbTx.ID) {
+		t.Error("transactions of the legacy block are lost")
+	}
+
+	// blocks of the current layout round trip
+	data, err = block.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	decoded := &Block{}
+	if err := decoded.Deserialize(data); err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+	if !bytes.Equal(decoded.Header.SealData(), block.Header.SealData()) || !bytes.Equal(decoded.Hash, block.Hash) {
+		t.Errorf("header %+v, want %+v", decoded.Header, block.Header)
+	}
+}
@@ -2,34 +2,127 @@ package blockchain
 
 import (
 	"bytes"
-	"crypto/ecdsa"
+	"context"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sync"
 
 	"github.com/dgraph-io/badger"
+
+	"blockchain/pkg/chaincfg"
+	"blockchain/pkg/consensus"
+	"blockchain/pkg/crypto"
+	"blockchain/pkg/wallet"
 )
 
 const (
-	dbPath      = "./tmp/blocks"
-	dbFile      = "./tmp/blocks/MANIFEST"
-	genesisData = "First Transaction from Genesis"
+	// DefaultDataDir is where the block database lives unless WithDataDir is given
+	DefaultDataDir = "./tmp/blocks"
+	dbFile         = "MANIFEST"
+	genesisData    = "First Transaction from Genesis"
+	paramsKey      = "params"
 )
 
 type BlockChain struct {
 	database *badger.DB
-	lastHash []byte
+
+	// lastHash is the hash of the tip, it changes once a block is committed
+	lastHashMu sync.RWMutex
+	lastHash   []byte
+
+	dataDir    string
+	params     *chaincfg.Params
+	engine     consensus.Engine
+	timeSource TimeSource
+
+	noAssumeValid bool
+	addrIndex     bool
+
+	genesisExtra []byte
+
+	events *notifier
+}
+
+type BlockChainOpt func(*BlockChain)
+
+// WithDataDir stores the block database in dir, so several chains can live side by side
+func WithDataDir(dir string) BlockChainOpt {
+	return func(bc *BlockChain) {
+		if dir != "" {
+			bc.dataDir = dir
+		}
+	}
+}
+
+// WithParams selects the network of the chain. A new chain stores it,
+// an existing chain is only opened if it belongs to the same network.
+func WithParams(params *chaincfg.Params) BlockChainOpt {
+	return func(bc *BlockChain) {
+		bc.params = params
+	}
 }
 
-func InitBlockChain(address string) (*BlockChain, error) {
-	if dbExists() {
+// WithGenesisExtra sets the engine specific data of the genesis block
+// of a new chain, like the initial signers of a proof of authority network
+func WithGenesisExtra(extra []byte) BlockChainOpt {
+	return func(bc *BlockChain) {
+		bc.genesisExtra = extra
+	}
+}
+
+// WithTimeSource sets the clock block timestamps are checked against,
+// the local clock corrected by peers through a MedianTime by default
+func WithTimeSource(ts TimeSource) BlockChainOpt {
+	return func(bc *BlockChain) {
+		bc.timeSource = ts
+	}
+}
+
+// WithoutAssumeValid checks the scripts and signatures of every block,
+// even those below the assume valid block of the network
+func WithoutAssumeValid() BlockChainOpt {
+	return func(bc *BlockChain) {
+		bc.noAssumeValid = true
+	}
+}
+
+// WithAddressIndex maintains an index of the transactions touching each
+// address, see History. It is built on open if missing or out of date.
+func WithAddressIndex() BlockChainOpt {
+	return func(bc *BlockChain) {
+		bc.addrIndex = true
+	}
+}
+
+func newBlockChain(opts ...BlockChainOpt) *BlockChain {
+	bc := &BlockChain{
+		dataDir:    DefaultDataDir,
+		timeSource: NewMedianTime(),
+		events:     newNotifier(),
+	}
+	for _, opt := range opts {
+		opt(bc)
+	}
+
+	return bc
+}
+
+func InitBlockChain(address string, opts ...BlockChainOpt) (*BlockChain, error) {
+	bc := newBlockChain(opts...)
+	if dbExists(bc.dataDir) {
 		return nil, ErrorBCExists
 	}
+	if bc.params == nil {
+		bc.params = chaincfg.DefaultParams
+	}
+	bc.engine = bc.params.NewEngine()
 
-	opts := badger.DefaultOptions(dbPath)
-	opts.Logger = nil
-	db, err := badger.Open(opts)
+	dbOpts := badger.DefaultOptions(bc.dataDir)
+	dbOpts.Logger = nil
+	db, err := badger.Open(dbOpts)
 	if err != nil {
 		return nil, fmt.Errorf("error while opening database: %w", err)
 	}
@@ -43,6 +136,10 @@ func InitBlockChain(address string) (*BlockChain, error) {
 		}
 
 		genesis := Genesis(cbtx)
+		genesis.Extra = bc.genesisExtra
+		if err1 := bc.SealBlock(context.Background(), genesis); err1 != nil {
+			return fmt.Errorf("error while sealing genesis block: %w", err1)
+		}
 		fmt.Println("Genesis created")
 
 		encodedGenesis, err1 := genesis.Serialize()
@@ -52,32 +149,56 @@ func InitBlockChain(address string) (*BlockChain, error) {
 		if err1 := txn.Set(genesis.Hash, encodedGenesis); err1 != nil {
 			return fmt.Errorf("error while setting genesis block: %w", err1)
 		}
+		if err1 := txn.Set(heightKey(genesis.Height), genesis.Hash); err1 != nil {
+			return fmt.Errorf("error while indexing genesis block: %w", err1)
+		}
 
 		if err1 := txn.Set([]byte("lh"), genesis.Hash); err1 != nil {
 			return fmt.Errorf("error while setting last hash: %w", err1)
 		}
 
+		if err1 := txn.Set([]byte(paramsKey), []byte(bc.params.Name)); err1 != nil {
+			return fmt.Errorf("error while setting network: %w", err1)
+		}
+
+		if err1 := updateUTXOs(txn, genesis); err1 != nil {
+			return fmt.Errorf("error while updating UTXO set: %w", err1)
+		}
+		if err1 := txn.Set(utxoVersionKey, []byte{utxoVersion}); err1 != nil {
+			return fmt.Errorf("error while setting UTXO set version: %w", err1)
+		}
+
 		lastHash = genesis.Hash
 
 		return nil
 	})
 	if err != nil {
 		_ = db.Close()
-		_ = os.RemoveAll(dbPath)
+		_ = os.RemoveAll(bc.dataDir)
 		return nil, fmt.Errorf("error while updating blockchain: %w", err)
 	}
 
-	return &BlockChain{database: db, lastHash: lastHash}, nil
+	bc.database, bc.lastHash = db, lastHash
+
+	if bc.addrIndex {
+		if err := bc.syncAddressIndex(); err != nil {
+			bc.Close()
+			return nil, fmt.Errorf("error while building address index: %w", err)
+		}
+	}
+
+	return bc, nil
 }
 
-func ContinueBlockChain() (*BlockChain, error) {
-	if !dbExists() {
+func ContinueBlockChain(opts ...BlockChainOpt) (*BlockChain, error) {
+	bc := newBlockChain(opts...)
+	if !dbExists(bc.dataDir) {
 		return nil, errors.New("no existing blockchain found. Create one first")
 	}
 
-	opts := badger.DefaultOptions(dbPath)
-	opts.Logger = nil
-	db, err := badger.Open(opts)
+	dbOpts := badger.DefaultOptions(bc.dataDir)
+	dbOpts.Logger = nil
+	db, err := badger.Open(dbOpts)
 	if err != nil {
 		return nil, fmt.Errorf("error while opening database: %w", err)
 	}
@@ -96,19 +217,186 @@ func ContinueBlockChain() (*BlockChain, error) {
 		})
 	})
 	if err != nil {
+		_ = db.Close()
 		return nil, fmt.Errorf("error while getting last hash: %w", err)
 	}
 
-	return &BlockChain{database: db, lastHash: lastHash}, nil
+	params, err := loadParams(db)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	if bc.params != nil && bc.params.Name != params.Name {
+		_ = db.Close()
+		return nil, fmt.Errorf("%w: chain is %s, not %s", ErrorBCNetwork, params.Name, bc.params.Name)
+	}
+
+	bc.database, bc.lastHash, bc.params = db, lastHash, params
+	bc.engine = params.NewEngine()
+
+	if err := bc.indexHeights(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("error while indexing block heights: %w", err)
+	}
+	if err := bc.syncUTXOSet(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("error while rebuilding UTXO set: %w", err)
+	}
+	if bc.addrIndex {
+		if err := bc.syncAddressIndex(); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("error while building address index: %w", err)
+		}
+	}
+
+	return bc, nil
+}
+
+// loadParams returns the stored network parameters, chains created
+// before they were stored use the default network
+func loadParams(db *badger.DB) (*chaincfg.Params, error) {
+	var name string
+
+	err := db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(paramsKey))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			name = string(val)
+
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error while getting network: %w", err)
+	}
+
+	if name == "" {
+		return chaincfg.DefaultParams, nil
+	}
+
+	return chaincfg.ParamsByName(name)
+}
+
+// Params returns the parameters of the network the chain belongs to
+func (bc *BlockChain) Params() *chaincfg.Params {
+	return bc.params
+}
+
+// TimeSource returns the clock block timestamps are checked against. Unless
+// WithTimeSource is given it is a *MedianTime, peers report their clocks to it.
+func (bc *BlockChain) TimeSource() TimeSource {
+	return bc.timeSource
+}
+
+// Engine returns the consensus engine of the network
+func (bc *BlockChain) Engine() consensus.Engine {
+	return bc.engine
+}
+
+// LastHash returns the hash of the chain tip
+func (bc *BlockChain) LastHash() []byte {
+	bc.lastHashMu.RLock()
+	defer bc.lastHashMu.RUnlock()
+
+	return bc.lastHash
+}
+
+func (bc *BlockChain) setLastHash(hash []byte) {
+	bc.lastHashMu.Lock()
+	defer bc.lastHashMu.Unlock()
+
+	bc.lastHash = hash
+}
+
+// SealBlock lets the consensus engine complete the header of block
+func (bc *BlockChain) SealBlock(ctx context.Context, block *Block) error {
+	if err := bc.engine.Prepare(bc, &block.Header); err != nil {
+		return err
+	}
+
+	return bc.engine.Seal(ctx, &block.Header)
+}
+
+// GetBlock returns the block with the given hash
+func (bc *BlockChain) GetBlock(hash []byte) (*Block, error) {
+	block := &Block{}
+
+	err := bc.database.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(hash)
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return fmt.Errorf("%w: %x", ErrorBlkNotFound, hash)
+		}
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			return block.Deserialize(val)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error while getting block: %w", err)
+	}
+
+	return block, nil
+}
+
+// GetHeader returns the header of the block with the given hash
+func (bc *BlockChain) GetHeader(hash []byte) (*consensus.Header, error) {
+	block, err := bc.GetBlock(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &block.Header, nil
 }
 
 func (bc *BlockChain) AddBlock(block *Block) error {
-	return bc.database.Update(func(txn *badger.Txn) error {
-		if _, err := txn.Get(block.Hash); err == nil {
+	if hash, ok := bc.params.Checkpoint(block.Height); ok && !bytes.Equal(hash, block.Hash) {
+		return fmt.Errorf("error while adding block: %w: height %d, expected %x", ErrorBlkCheckpoint, block.Height, hash)
+	}
+
+	if err := bc.engine.VerifySeal(bc, &block.Header); err != nil {
+		return fmt.Errorf("error while adding block: %w", err)
+	}
+
+	mtp, err := bc.MedianTimePast(block.PrevHash)
+	if err != nil {
+		return fmt.Errorf("error while adding block: %w", err)
+	}
+	if err := bc.verifyTimestamp(block, mtp); err != nil {
+		return fmt.Errorf("error while adding block: %w", err)
+	}
+
+	if err := bc.verifyTransactions(block); err != nil {
+		return fmt.Errorf("error while adding block: %w", err)
+	}
+
+	connected := false
+	err = bc.database.Update(func(txn *badger.Txn) error {
+		// blocks disconnected from the main chain stay stored, only a block
+		// on the main chain is already added
+		if hash, err := bc.mainChainHash(txn, block.Height); err == nil && bytes.Equal(hash, block.Hash) {
 			return nil
 		}
 
-		item, err := txn.Get(bc.lastHash)
+		// reading the tip in txn makes a concurrent AddBlock moving it a conflict
+		item, err := txn.Get([]byte("lh"))
+		if err != nil {
+			return fmt.Errorf("error while getting last hash: %w", err)
+		}
+		lastHash, err := item.ValueCopy(nil)
+		if err != nil {
+			return fmt.Errorf("error while getting last hash: %w", err)
+		}
+
+		item, err = txn.Get(lastHash)
 		if err != nil {
 			return fmt.Errorf("error while getting last block: %w", err)
 		}
@@ -128,6 +416,24 @@ func (bc *BlockChain) AddBlock(block *Block) error {
 			return fmt.Errorf("error while adding block: %w, lastBlock's hash: %x, block's prevHash: %x", ErrorBlkPrevHashInvalid, lastBlock.Hash, block.PrevHash)
 		}
 
+		if err := block.verifyCommitments(); err != nil {
+			return fmt.Errorf("error while adding block: %w", err)
+		}
+
+		if err := block.verifyLimits(bc.params); err != nil {
+			return fmt.Errorf("error while adding block: %w", err)
+		}
+
+		if err := verifyBlockValue(txn, block); err != nil {
+			return fmt.Errorf("error while adding block: %w", err)
+		}
+
+		for _, tx := range block.Transactions {
+			if !tx.IsFinal(block.Height, mtp) {
+				return fmt.Errorf("error while adding block: %w, transaction: %x", ErrorTxNotFinal, tx.ID)
+			}
+		}
+
 		blockData, err := block.Serialize()
 		if err != nil {
 			return fmt.Errorf("error while serializing block: %w", err)
@@ -137,19 +443,53 @@ func (bc *BlockChain) AddBlock(block *Block) error {
 			return fmt.Errorf("error while setting block: %w", err)
 		}
 
+		if err := txn.Set(heightKey(block.Height), block.Hash); err != nil {
+			return fmt.Errorf("error while indexing block: %w", err)
+		}
+
 		if err := txn.Set([]byte("lh"), block.Hash); err != nil {
 			return fmt.Errorf("error while setting last hash: %w", err)
 		}
 
-		bc.lastHash = block.Hash
+		// the index looks the outputs spent up before the UTXO set drops them
+		if bc.addrIndex {
+			entries, err := blockAddressEntries(txn, block)
+			if err != nil {
+				return fmt.Errorf("error while adding block: %w", err)
+			}
+			if err := putAddressEntries(txn, entries, block.Hash, false); err != nil {
+				return err
+			}
+		}
+
+		if err := updateUTXOs(txn, block); err != nil {
+			return fmt.Errorf("error while adding block: %w", err)
+		}
+
+		connected = true
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	if connected {
+		bc.setLastHash(block.Hash)
+		bc.events.publish(Event{Type: EventBlockConnected, Block: block})
+	}
+
+	return nil
 }
 
 func (bc *BlockChain) MineBlock(transactions []*Transaction) (*Block, error) {
+	return bc.MineBlockContext(context.Background(), transactions)
+}
+
+// MineBlockContext mines and adds a block like MineBlock, giving up when ctx is done
+func (bc *BlockChain) MineBlockContext(ctx context.Context, transactions []*Transaction) (*Block, error) {
 	var lastHeight int
-	lastHash := bc.lastHash
+	lastHash := bc.LastHash()
 
 	for _, tx := range transactions {
 		if !bc.VerifyTransaction(tx) {
@@ -181,7 +521,27 @@ func (bc *BlockChain) MineBlock(transactions []*Transaction) (*Block, error) {
 		return nil, fmt.Errorf("error while getting last hash: %w", err)
 	}
 
-	block := CreateBlock(transactions, lastHash, lastHeight+1)
+	mtp, err := bc.MedianTimePast(lastHash)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting median time past: %w", err)
+	}
+	for _, tx := range transactions {
+		if !tx.IsFinal(lastHeight+1, mtp) {
+			return nil, fmt.Errorf("while checking transaction %s: %w", hex.EncodeToString(tx.ID), ErrorTxNotFinal)
+		}
+	}
+
+	block := NewBlock(transactions, lastHash, lastHeight+1)
+	// a clock behind the chain must not make the block invalid
+	if block.Timestamp <= mtp {
+		block.Timestamp = mtp + 1
+	}
+	if err := block.verifyLimits(bc.params); err != nil {
+		return nil, err
+	}
+	if err := bc.SealBlock(ctx, block); err != nil {
+		return nil, fmt.Errorf("error while sealing block: %w", err)
+	}
 
 	err = bc.AddBlock(block)
 	if err != nil {
@@ -191,28 +551,30 @@ func (bc *BlockChain) MineBlock(transactions []*Transaction) (*Block, error) {
 	return block, nil
 }
 
-func (bc *BlockChain) FindUTXOs() map[string]*TxOutputs {
-	UTXOs := make(map[string]*TxOutputs)
+func (bc *BlockChain) FindUTXOs() (map[string]UnspentOutputs, error) {
+	UTXOs := make(map[string]UnspentOutputs)
 	spentTXOs := make(map[string]struct{})
 
 	iter := bc.Iterator()
-	for iter.HasNext() {
-		block := iter.Next()
-		txs := block.Transactions
+	for iter.Next() {
+		txs := iter.Block().Transactions
 		for _, tx := range txs {
 			txID := hex.EncodeToString(tx.ID)
 		Output:
 			for outIdx, out := range tx.Outputs {
+				if !out.IsSpendable() {
+					continue Output
+				}
 				k := fmt.Sprintf("%s-%d", txID, outIdx)
 				if _, ok := spentTXOs[k]; ok {
 					continue Output
 				}
 				outs := UTXOs[txID]
 				if outs == nil {
-					outs = &TxOutputs{}
+					outs = UnspentOutputs{}
 					UTXOs[txID] = outs
 				}
-				*outs = append(*outs, out)
+				outs[outIdx] = out
 			}
 
 			if !tx.IsCoinbase() {
@@ -223,24 +585,42 @@ func (bc *BlockChain) FindUTXOs() map[string]*TxOutputs {
 			}
 		}
 	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("error while iterating blocks: %w", err)
+	}
 
-	return UTXOs
+	return UTXOs, nil
 }
 
 func (bc *BlockChain) FindTransaction(ID []byte) (*Transaction, error) {
+	tx, _, err := bc.FindTransactionBlock(ID)
+
+	return tx, err
+}
+
+// FindTransactionBlock returns the transaction with the given id and the
+// main chain block holding it
+func (bc *BlockChain) FindTransactionBlock(ID []byte) (*Transaction, *Block, error) {
 	iter := bc.Iterator()
-	for iter.HasNext() {
-		block := iter.Next()
-		for _, tx := range block.Transactions {
+	for iter.Next() {
+		for _, tx := range iter.Block().Transactions {
 			if bytes.Equal(tx.ID, ID) {
-				return tx, nil
+				return tx, iter.Block(), nil
 			}
 		}
 	}
-	return nil, ErrorTxNotFound
+	if err := iter.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error while iterating blocks: %w", err)
+	}
+	return nil, nil, ErrorTxNotFound
+}
+
+func (bc *BlockChain) SignTransaction(tx *Transaction, privKey crypto.Signer) error {
+	return bc.SignTransactionWithHashType(tx, privKey, SigHashAll)
 }
 
-func (bc *BlockChain) SignTransaction(tx *Transaction, privKey *ecdsa.PrivateKey) error {
+// SignTransactionWithHashType signs the inputs owned by privKey with the given signature hash type
+func (bc *BlockChain) SignTransactionWithHashType(tx *Transaction, privKey crypto.Signer, hashType SigHashType) error {
 	prevTXs := make(map[string]*Transaction)
 
 	for _, in := range tx.Inputs {
@@ -250,14 +630,22 @@ func (bc *BlockChain) SignTransaction(tx *Transaction, privKey *ecdsa.PrivateKey
 			prevTXs[hex.EncodeToString(prevTx.ID)] = prevTx
 		}
 	}
-	return tx.Sign(privKey, prevTXs)
+	return tx.SignWithHashType(privKey, prevTXs, hashType)
 }
 
 func (bc *BlockChain) VerifyTransaction(tx *Transaction) bool {
+	return bc.verifyTransaction(tx, true)
+}
+
+func (bc *BlockChain) verifyTransaction(tx *Transaction, checkScripts bool) bool {
 	if tx.IsCoinbase() {
 		return true
 	}
 
+	if !bc.spendsUnspent(tx) || !bc.usesNetworkCurve(tx) {
+		return false
+	}
+
 	prevTXs := make(map[string]*Transaction)
 
 	for _, in := range tx.Inputs {
@@ -267,14 +655,112 @@ func (bc *BlockChain) VerifyTransaction(tx *Transaction) bool {
 			prevTXs[hex.EncodeToString(prevTx.ID)] = prevTx
 		}
 	}
-	return tx.Verify(prevTXs)
+	return tx.verify(prevTXs, checkScripts)
+}
+
+// usesNetworkCurve reports whether the ECDSA keys spending the inputs of tx
+// lie on the curve of the network, a key of another curve is rejected even
+// with a valid signature
+func (bc *BlockChain) usesNetworkCurve(tx *Transaction) bool {
+	for _, in := range tx.Inputs {
+		pubKeys := [][]byte{in.PubKey}
+		if len(in.Redeem) > 0 {
+			script, err := wallet.ParseMultisigScript(in.Redeem)
+			if err != nil {
+				return false
+			}
+			pubKeys = append(pubKeys, script.PubKeys...)
+		}
+
+		for _, pubKey := range pubKeys {
+			if len(pubKey) == 0 {
+				continue
+			}
+			key, err := crypto.ParsePublicKey(pubKey)
+			if err != nil {
+				return false
+			}
+			if ecKey, ok := key.(*crypto.ECDSAPublicKey); ok && ecKey.Curve() != bc.params.Curve {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// verifyTransactions checks the transactions of a block, skipping scripts
+// and signatures below the assume valid block
+func (bc *BlockChain) verifyTransactions(block *Block) error {
+	checkScripts := !bc.assumedValid(block)
+
+	for _, tx := range block.Transactions {
+		if !bc.verifyTransaction(tx, checkScripts) {
+			return fmt.Errorf("%w: %x", ErrorTxInvalid, tx.ID)
+		}
+	}
+
+	return nil
+}
+
+// verifyBlockValue checks that no transaction of block pays more than it
+// spends and that its coinbases pay at most the miner reward and the fees,
+// what the other transactions spend and don't pay. It reads the spent
+// outputs from the UTXO set, before the block updates it.
+func verifyBlockValue(txn *badger.Txn, block *Block) error {
+	paid, fees := 0, 0
+
+	for _, tx := range block.Transactions {
+		if tx.IsCoinbase() {
+			for _, out := range tx.Outputs {
+				paid += out.Value
+			}
+			continue
+		}
+
+		spent := 0
+		for _, in := range tx.Inputs {
+			outs, err := unspentOutputs(txn, in.ID)
+			if err != nil {
+				return fmt.Errorf("error while reading utxo set: %w", err)
+			}
+			out, ok := outs[in.Out]
+			if !ok {
+				return fmt.Errorf("%w: %x", ErrorTxDoubleSpend, tx.ID)
+			}
+			spent += out.Value
+		}
+		for _, out := range tx.Outputs {
+			spent -= out.Value
+		}
+		if spent < 0 {
+			return fmt.Errorf("%w: %x pays %d more than it spends", ErrorTxInvalid, tx.ID, -spent)
+		}
+		fees += spent
+	}
+
+	if paid > minerReward+fees {
+		return fmt.Errorf("%w: coinbase pays %d, reward and fees are %d", ErrorBlkCoinbaseInvalid, paid, minerReward+fees)
+	}
+
+	return nil
+}
+
+// assumedValid reports whether the scripts of block are covered by the
+// assume valid block of the network, that is whether block is not above it.
+// The assume valid block is a checkpoint, so a chain whose scripts were not
+// checked can't be extended past its height unless it leads to it.
+func (bc *BlockChain) assumedValid(block *Block) bool {
+	av := bc.params.AssumeValid
+
+	return !bc.noAssumeValid && av != nil && block.Height <= av.Height
 }
 
 func (bc *BlockChain) GetBaseHeight() (int, error) {
-	var lastBlock *Block
+	lastBlock := &Block{}
 
 	err := bc.database.View(func(txn *badger.Txn) error {
-		item, err := txn.Get(bc.lastHash)
+		item, err := txn.Get(bc.LastHash())
 		if err != nil {
 			return fmt.Errorf("error while getting last block: %w", err)
 		}
@@ -300,15 +786,8 @@ func (bc *BlockChain) Close() {
 	_ = bc.database.Close()
 }
 
-func (bc *BlockChain) Iterator() *Iterator {
-	return &Iterator{
-		Database:    bc.database,
-		CurrentHash: bc.lastHash,
-	}
-}
-
-func dbExists() bool {
-	if _, err := os.Stat(dbFile); os.IsNotExist(err) {
+func dbExists(dataDir string) bool {
+	if _, err := os.Stat(filepath.Join(dataDir, dbFile)); os.IsNotExist(err) {
 		return false
 	}
 
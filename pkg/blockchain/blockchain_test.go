@@ -0,0 +1,125 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"blockchain/pkg/chaincfg"
+	"blockchain/pkg/crypto"
+	"blockchain/pkg/wallet"
+)
+
+func TestVerifyTransactionCurve(t *testing.T) {
+	for _, params := range []*chaincfg.Params{&chaincfg.DevNetParams, &chaincfg.MainNetParams} {
+		t.Run(params.Name, func(t *testing.T) {
+			for _, c := range []crypto.Curve{crypto.CurveP256, crypto.CurveSecp256k1} {
+				from, err := wallet.CreateWallet(crypto.KeyECDSA, crypto.WithCurve(c))
+				if err != nil {
+					t.Fatalf("CreateWallet: %v", err)
+				}
+				chain, err := InitBlockChain(from, WithDataDir(t.TempDir()), WithParams(params))
+				if err != nil {
+					t.Fatalf("InitBlockChain: %v", err)
+				}
+				t.Cleanup(chain.Close)
+
+				tx, err := NewTransaction(from, newTestWallet(t), 5, NewUTXOSet(chain))
+				if err != nil {
+					t.Fatalf("NewTransaction: %v", err)
+				}
+				if got, want := chain.VerifyTransaction(tx), c == params.Curve; got != want {
+					t.Errorf("%s key: VerifyTransaction = %v, want %v", c, got, want)
+				}
+			}
+		})
+	}
+}
+
+// sealTestBlock seals a block of txs on top of prev without adding it,
+// like a block received from a peer
+func sealTestBlock(t *testing.T, chain *BlockChain, prev *Block, miner string, txs ...*Transaction) *Block {
+	t.Helper()
+
+	cbTx, err := CoinbaseTx(miner, "")
+	if err != nil {
+		t.Fatalf("CoinbaseTx: %v", err)
+	}
+	block := NewBlock(append(txs, cbTx), prev.Hash, prev.Height+1)
+	block.Timestamp = prev.Timestamp + 1
+	if err := chain.SealBlock(context.Background(), block); err != nil {
+		t.Fatalf("SealBlock: %v", err)
+	}
+
+	return block
+}
+
+// badSignatureTx pays amount from from to to with a broken signature
+func badSignatureTx(t *testing.T, chain *BlockChain, from, to string, amount int) *Transaction {
+	t.Helper()
+
+	tx, err := NewTransaction(from, to, amount, NewUTXOSet(chain))
+	if err != nil {
+		t.Fatalf("NewTransaction: %v", err)
+	}
+	tx.Inputs[0].Signature[len(tx.Inputs[0].Signature)-1] ^= 1
+	if chain.VerifyTransaction(tx) {
+		t.Fatal("transaction with a broken signature verifies")
+	}
+
+	return tx
+}
+
+func TestAssumeValid(t *testing.T) {
+	for _, checkAll := range []bool{false, true} {
+		t.Run(fmt.Sprintf("checkAll=%v", checkAll), func(t *testing.T) {
+			alice, bob := newTestWallet(t), newTestWallet(t)
+
+			params := chaincfg.DevNetParams
+			opts := []BlockChainOpt{WithDataDir(t.TempDir()), WithParams(&params)}
+			if checkAll {
+				opts = append(opts, WithoutAssumeValid())
+			}
+			chain, err := InitBlockChain(alice, opts...)
+			if err != nil {
+				t.Fatalf("InitBlockChain: %v", err)
+			}
+			t.Cleanup(chain.Close)
+			genesis, err := chain.GetBlock(chain.LastHash())
+			if err != nil {
+				t.Fatalf("GetBlock: %v", err)
+			}
+
+			// the blocks up to the assume valid block come in before it is
+			// stored, like in an initial sync. Its hash is only checked at
+			// its height, it is set once the block is sealed.
+			params.AssumeValid = &chaincfg.Checkpoint{Height: 2}
+			b1 := sealTestBlock(t, chain, genesis, bob, badSignatureTx(t, chain, alice, bob, 5))
+			err = chain.AddBlock(b1)
+			if checkAll {
+				if !errors.Is(err, ErrorTxInvalid) {
+					t.Fatalf("AddBlock below the assume valid block: got %v, want %v", err, ErrorTxInvalid)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AddBlock below the assume valid block: %v", err)
+			}
+			b2 := sealTestBlock(t, chain, b1, bob)
+			params.AssumeValid.Hash = b2.Hash
+			if err := chain.AddBlock(b2); err != nil {
+				t.Fatalf("AddBlock of the assume valid block: %v", err)
+			}
+			if got, want := balance(t, chain, bob), 5+2*minerReward; got != want {
+				t.Errorf("balance of bob = %d, want %d", got, want)
+			}
+
+			// above it scripts are checked again
+			b3 := sealTestBlock(t, chain, b2, bob, badSignatureTx(t, chain, bob, alice, 5))
+			if err := chain.AddBlock(b3); !errors.Is(err, ErrorTxInvalid) {
+				t.Fatalf("AddBlock above the assume valid block: got %v, want %v", err, ErrorTxInvalid)
+			}
+		})
+	}
+}
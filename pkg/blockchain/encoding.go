@@ -0,0 +1,67 @@
+package blockchain
+
+import (
+	"encoding/binary"
+)
+
+// txEncoder builds the canonical encoding of a transaction that ids and
+// signature hashes are computed from. Unlike gob, which is still used for
+// storage, the output only depends on the encoded values.
+type txEncoder struct {
+	buf []byte
+}
+
+func (e *txEncoder) writeInt(v int64) {
+	e.buf = binary.AppendVarint(e.buf, v)
+}
+
+func (e *txEncoder) writeBytes(b []byte) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+// encode writes the transaction without its id. The witness of the inputs
+// is only included with withWitness, it is appended after all other fields.
+func (tx *Transaction) encode(withWitness bool) []byte {
+	e := &txEncoder{}
+
+	e.writeInt(int64(len(tx.Inputs)))
+	for _, in := range tx.Inputs {
+		e.writeBytes(in.ID)
+		e.writeInt(int64(in.Out))
+		e.writeBytes(in.PubKey)
+		e.writeBytes(in.Redeem)
+		e.writeBytes(in.Preimage)
+	}
+
+	e.writeInt(int64(len(tx.Outputs)))
+	for _, out := range tx.Outputs {
+		e.writeInt(int64(out.Value))
+		e.writeBytes(out.PubKeyHash)
+		e.writeInt(int64(out.Type))
+		if out.HTLC != nil {
+			e.writeInt(1)
+			e.writeBytes(out.HTLC.Recipient)
+			e.writeBytes(out.HTLC.Sender)
+			e.writeBytes(out.HTLC.SecretHash)
+			e.writeInt(out.HTLC.Timeout)
+		} else {
+			e.writeInt(0)
+		}
+		e.writeBytes(out.Data)
+	}
+
+	e.writeInt(tx.LockTime)
+
+	if withWitness {
+		for _, in := range tx.Inputs {
+			e.writeBytes(in.Signature)
+			e.writeInt(int64(len(in.Signatures)))
+			for _, sig := range in.Signatures {
+				e.writeBytes(sig)
+			}
+		}
+	}
+
+	return e.buf
+}
@@ -7,12 +7,34 @@ import (
 var (
 	ErrorBCNotFound = errors.New("blockchain not found")
 	ErrorBCExists   = errors.New("blockchain already exists")
+	ErrorBCNetwork  = errors.New("blockchain belongs to another network")
 
 	ErrorBlkHeightInvalid   = errors.New("block height is invalid")
 	ErrorBlkPrevHashInvalid = errors.New("block previous hash is invalid")
+	ErrorBlkWitnessInvalid  = errors.New("block witness root is invalid")
+	ErrorBlkTxRootInvalid   = errors.New("block transaction root is invalid")
+	ErrorBlkNotFound        = errors.New("block not found")
+	ErrorBlkCoinbaseInvalid = errors.New("block coinbase is invalid")
+	ErrorBlkTooLarge        = errors.New("block is too large")
+	ErrorBlkTooManySigOps   = errors.New("block has too many signature operations")
+	ErrorBlkTimeTooOld      = errors.New("block timestamp is not after the median time past")
+	ErrorBlkTimeTooNew      = errors.New("block timestamp is too far in the future")
+	ErrorBlkCheckpoint      = errors.New("block conflicts with a checkpoint")
+
+	ErrorSubscriptionOverflow = errors.New("subscriber fell behind the chain events")
+
+	ErrorTemplateUnsupported = errors.New("consensus engine does not support block templates")
 
 	ErrorTxNotFound     = errors.New("transaction not found")
 	ErrorTxSignFailed   = errors.New("transaction signing failed")
 	ErrorTxCreateFailed = errors.New("transaction creation failed")
 	ErrorTxInvalid      = errors.New("transaction is invalid")
+	ErrorTxNotFinal     = errors.New("transaction is not final")
+	ErrorTxDoubleSpend  = errors.New("transaction output is already spent")
+
+	ErrorHTLCNotClaimed = errors.New("htlc has not been claimed")
+
+	ErrorAnchorNotFound = errors.New("anchor not found")
+
+	ErrorAddrIndexDisabled = errors.New("address index is disabled")
 )
@@ -0,0 +1,248 @@
+package blockchain
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultEventBuffer is the number of events a subscription holds for its
+// subscriber unless WithBufferSize is given
+const DefaultEventBuffer = 64
+
+// EventType identifies what happened to the chain
+type EventType int
+
+const (
+	// EventBlockConnected is published once a block extends the main chain
+	EventBlockConnected EventType = iota
+	// EventBlockDisconnected is published once the tip is removed from the
+	// main chain. The chain only ever extends its tip for now, the type is
+	// there so subscribers are ready once it can reorganize.
+	EventBlockDisconnected
+	// EventTxAccepted is published once a transaction enters the mempool
+	EventTxAccepted
+	// EventReorg is published after a reorganization, following the events
+	// of the blocks it disconnected and connected. Like EventBlockDisconnected
+	// it is not published yet.
+	EventReorg
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventBlockConnected:
+		return "block connected"
+	case EventBlockDisconnected:
+		return "block disconnected"
+	case EventTxAccepted:
+		return "transaction accepted"
+	case EventReorg:
+		return "reorg"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is published to the subscribers of a chain. Block is set for the
+// block events, Tx for EventTxAccepted and Reorg for EventReorg. Subscribers
+// share the values and must not modify them.
+type Event struct {
+	Type  EventType
+	Block *Block
+	Tx    *Transaction
+	Reorg *Reorg
+}
+
+// Reorg describes a switch of the main chain to another branch
+type Reorg struct {
+	// ForkHash and ForkHeight identify the last block both branches share
+	ForkHash   []byte
+	ForkHeight int
+	// Disconnected lists the blocks of the old branch from its tip down,
+	// Connected the blocks of the new branch from the fork up
+	Disconnected []*Block
+	Connected    []*Block
+}
+
+// Backpressure is what a subscription does with an event its subscriber
+// has no room for
+type Backpressure int
+
+const (
+	// BackpressureClose ends the subscription, Err returns ErrorSubscriptionOverflow
+	BackpressureClose Backpressure = iota
+	// BackpressureDrop discards the event, Dropped counts them
+	BackpressureDrop
+	// BackpressureBlock makes the chain wait for room, a slow subscriber
+	// slows down everything adding blocks
+	BackpressureBlock
+)
+
+// Subscription delivers the events of a chain. Receive from Events until it
+// is closed, then Err tells why.
+type Subscription struct {
+	n *notifier
+
+	events       chan Event
+	types        map[EventType]struct{}
+	backpressure Backpressure
+
+	done    chan struct{}
+	once    sync.Once
+	dropped atomic.Uint64
+	err     error
+}
+
+type SubscribeOpt func(*Subscription)
+
+// WithBufferSize sets the number of events waiting for the subscriber
+// before backpressure applies
+func WithBufferSize(size int) SubscribeOpt {
+	if size <= 0 {
+		size = DefaultEventBuffer
+	}
+	return func(s *Subscription) {
+		s.events = make(chan Event, size)
+	}
+}
+
+// WithEventTypes only delivers events of the given types, all of them by default
+func WithEventTypes(types ...EventType) SubscribeOpt {
+	return func(s *Subscription) {
+		s.types = make(map[EventType]struct{}, len(types))
+		for _, t := range types {
+			s.types[t] = struct{}{}
+		}
+	}
+}
+
+// WithBackpressure sets what happens once the buffer is full, the
+// subscription is closed by default so missed events never go unnoticed
+func WithBackpressure(bp Backpressure) SubscribeOpt {
+	return func(s *Subscription) {
+		s.backpressure = bp
+	}
+}
+
+// Subscribe returns a subscription to the events of the chain published
+// from now on
+func (bc *BlockChain) Subscribe(opts ...SubscribeOpt) *Subscription {
+	s := &Subscription{
+		n:    bc.events,
+		done: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.events == nil {
+		s.events = make(chan Event, DefaultEventBuffer)
+	}
+
+	bc.events.add(s)
+
+	return s
+}
+
+// NotifyTxAccepted publishes EventTxAccepted. The chain keeps no mempool,
+// whoever accepts transactions into one calls it.
+func (bc *BlockChain) NotifyTxAccepted(tx *Transaction) {
+	bc.events.publish(Event{Type: EventTxAccepted, Tx: tx})
+}
+
+// Events returns the channel events are delivered on, it is closed when
+// the subscription ends
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Dropped returns the number of events discarded for lack of room
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Err returns ErrorSubscriptionOverflow if the subscription was closed
+// because its subscriber fell behind, nil otherwise
+func (s *Subscription) Err() error {
+	s.n.mu.Lock()
+	defer s.n.mu.Unlock()
+
+	return s.err
+}
+
+// Unsubscribe ends the subscription and closes the events channel, events
+// already buffered can still be received
+func (s *Subscription) Unsubscribe() {
+	// a publisher waiting for room gives up once done is closed
+	s.once.Do(func() { close(s.done) })
+
+	s.n.mu.Lock()
+	defer s.n.mu.Unlock()
+
+	s.n.remove(s, nil)
+}
+
+func (s *Subscription) wants(t EventType) bool {
+	if s.types == nil {
+		return true
+	}
+	_, ok := s.types[t]
+
+	return ok
+}
+
+// notifier fans the events of a chain out to its subscriptions. Publishing
+// is serialized so every subscriber sees the events in the same order.
+type notifier struct {
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+}
+
+func newNotifier() *notifier {
+	return &notifier{subs: make(map[*Subscription]struct{})}
+}
+
+func (n *notifier) add(s *Subscription) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.subs[s] = struct{}{}
+}
+
+// remove ends s with err, the caller holds mu
+func (n *notifier) remove(s *Subscription, err error) {
+	if _, ok := n.subs[s]; !ok {
+		return
+	}
+	delete(n.subs, s)
+
+	s.err = err
+	s.once.Do(func() { close(s.done) })
+	close(s.events)
+}
+
+func (n *notifier) publish(ev Event) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for s := range n.subs {
+		if !s.wants(ev.Type) {
+			continue
+		}
+
+		if s.backpressure == BackpressureBlock {
+			select {
+			case s.events <- ev:
+			case <-s.done:
+			}
+			continue
+		}
+
+		select {
+		case s.events <- ev:
+		default:
+			s.dropped.Add(1)
+			if s.backpressure == BackpressureClose {
+				n.remove(s, ErrorSubscriptionOverflow)
+			}
+		}
+	}
+}
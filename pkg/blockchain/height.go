@@ -0,0 +1,80 @@
+package blockchain
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/dgraph-io/badger"
+)
+
+// heightPrefix keys the hashes of the blocks of the main chain by height
+var heightPrefix = []byte("height-")
+
+func heightKey(height int) []byte {
+	return binary.BigEndian.AppendUint64(append([]byte{}, heightPrefix...), uint64(height))
+}
+
+// BlockHash returns the hash of the block of the main chain at height
+func (bc *BlockChain) BlockHash(height int) ([]byte, error) {
+	var hash []byte
+
+	err := bc.database.View(func(txn *badger.Txn) error {
+		var err error
+		hash, err = bc.mainChainHash(txn, height)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error while getting block hash: %w", err)
+	}
+
+	return hash, nil
+}
+
+func (bc *BlockChain) mainChainHash(txn *badger.Txn, height int) ([]byte, error) {
+	item, err := txn.Get(heightKey(height))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, fmt.Errorf("%w: no block at height %d", ErrorBlkNotFound, height)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return item.ValueCopy(nil)
+}
+
+// indexHeights fills the height index of chains created before it existed
+func (bc *BlockChain) indexHeights() error {
+	lastHash := bc.LastHash()
+	tip, err := bc.GetHeader(lastHash)
+	if err != nil {
+		return err
+	}
+	if _, err := bc.BlockHash(tip.Height); err == nil {
+		return nil
+	}
+
+	return bc.database.Update(func(txn *badger.Txn) error {
+		hash := lastHash
+		for len(hash) > 0 {
+			item, err := txn.Get(hash)
+			if err != nil {
+				return fmt.Errorf("error while getting block %x: %w", hash, err)
+			}
+
+			block := &Block{}
+			if err := item.Value(func(val []byte) error {
+				return block.Deserialize(val)
+			}); err != nil {
+				return fmt.Errorf("error while deserializing block %x: %w", hash, err)
+			}
+
+			if err := txn.Set(heightKey(block.Height), block.Hash); err != nil {
+				return err
+			}
+			hash = block.PrevHash
+		}
+
+		return nil
+	})
+}
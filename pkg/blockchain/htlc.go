@@ -0,0 +1,202 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"blockchain/pkg/crypto"
+	"blockchain/pkg/util"
+	"blockchain/pkg/wallet"
+)
+
+// HTLC is a hash time-locked contract. The recipient claims the locked value
+// by revealing the preimage of SecretHash, the sender takes it back once the
+// Timeout lock time has passed. Two HTLCs sharing a SecretHash on different
+// chains make an atomic swap.
+type HTLC struct {
+	Recipient  []byte
+	Sender     []byte
+	SecretHash []byte
+	// Timeout is a block height or unix time, see LockTimeThreshold
+	Timeout int64
+}
+
+// Hash returns the hash the HTLC output is locked with
+func (h *HTLC) Hash() []byte {
+	data := bytes.Join(
+		[][]byte{
+			h.Recipient,
+			h.Sender,
+			h.SecretHash,
+			util.MustInt64ToHex(h.Timeout),
+		},
+		[]byte{},
+	)
+
+	return crypto.HashPublicKey(data)
+}
+
+// refundable reports whether a transaction with lockTime may refund the contract
+func (h *HTLC) refundable(lockTime int64) bool {
+	if (lockTime < LockTimeThreshold) != (h.Timeout < LockTimeThreshold) {
+		return false
+	}
+
+	return lockTime >= h.Timeout
+}
+
+func NewHTLCOutput(value int, from, to string, secretHash []byte, timeout int64) (*TxOutput, error) {
+	if len(secretHash) != sha256.Size {
+		return nil, fmt.Errorf("secret hash must be %d bytes", sha256.Size)
+	}
+	if timeout <= 0 {
+		return nil, errors.New("timeout must be positive")
+	}
+
+	sender, err := wallet.PubKeyHashFromAddress(from)
+	if err != nil {
+		return nil, err
+	}
+	recipient, err := wallet.PubKeyHashFromAddress(to)
+	if err != nil {
+		return nil, err
+	}
+
+	contract := &HTLC{
+		Recipient:  recipient,
+		Sender:     sender,
+		SecretHash: secretHash,
+		Timeout:    timeout,
+	}
+	return &TxOutput{
+		Value:      value,
+		PubKeyHash: contract.Hash(),
+		Type:       OutputHTLC,
+		HTLC:       contract,
+	}, nil
+}
+
+// NewHTLCTransaction locks amount from the from wallet in a HTLC payable to to
+func NewHTLCTransaction(from, to string, amount int, secretHash []byte, timeout int64, utxo *UTXOSet, opts ...TxOpt) (*Transaction, error) {
+	out, err := NewHTLCOutput(amount, from, to, secretHash, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrorTxCreateFailed, err)
+	}
+
+	return fundTransaction(from, out, utxo, opts...)
+}
+
+// NewHTLCClaimTransaction pays a HTLC output to its recipient by revealing the secret
+func NewHTLCClaimTransaction(htlcTxID []byte, outIdx int, preimage []byte, utxo *UTXOSet) (*Transaction, error) {
+	out, err := utxo.findHTLCOutput(htlcTxID, outIdx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrorTxCreateFailed, err)
+	}
+
+	secretHash := sha256.Sum256(preimage)
+	if !bytes.Equal(secretHash[:], out.HTLC.SecretHash) {
+		return nil, fmt.Errorf("%w: preimage does not match the secret hash", ErrorTxCreateFailed)
+	}
+
+	return spendHTLC(htlcTxID, outIdx, out, out.HTLC.Recipient, preimage, 0, utxo)
+}
+
+// NewHTLCRefundTransaction pays a HTLC output back to its sender, the
+// transaction only becomes final once the contract has timed out
+func NewHTLCRefundTransaction(htlcTxID []byte, outIdx int, utxo *UTXOSet) (*Transaction, error) {
+	out, err := utxo.findHTLCOutput(htlcTxID, outIdx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrorTxCreateFailed, err)
+	}
+
+	return spendHTLC(htlcTxID, outIdx, out, out.HTLC.Sender, nil, out.HTLC.Timeout, utxo)
+}
+
+func spendHTLC(htlcTxID []byte, outIdx int, out *TxOutput, owner, preimage []byte, lockTime int64, utxo *UTXOSet) (*Transaction, error) {
+	address, err := wallet.AddressFromPubKeyHash(owner)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrorTxCreateFailed, err)
+	}
+
+	w, err := wallet.GetWallet(address)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrorTxCreateFailed, err)
+	}
+
+	in := NewTxInput(htlcTxID, outIdx, w.PublicKeyBytes())
+	in.Preimage = preimage
+
+	tx := &Transaction{
+		Inputs:   []TxInput{*in},
+		Outputs:  []TxOutput{*NewTXOutput(out.Value, address)},
+		LockTime: lockTime,
+	}
+	if err := tx.SetID(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrorTxCreateFailed, err)
+	}
+
+	if err := utxo.SignTransaction(tx, w.PrivateKey); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrorTxCreateFailed, err)
+	}
+
+	return tx, nil
+}
+
+func (bc *BlockChain) findHTLCOutput(htlcTxID []byte, outIdx int) (*TxOutput, error) {
+	tx, err := bc.FindTransaction(htlcTxID)
+	if err != nil {
+		return nil, err
+	}
+
+	if outIdx < 0 || outIdx >= len(tx.Outputs) || tx.Outputs[outIdx].Type != OutputHTLC {
+		return nil, fmt.Errorf("output %d of transaction %x is not a htlc", outIdx, htlcTxID)
+	}
+
+	return &tx.Outputs[outIdx], nil
+}
+
+// FindHTLCSecret returns the secret revealed by the claim of a HTLC output
+func (bc *BlockChain) FindHTLCSecret(htlcTxID []byte, outIdx int) ([]byte, error) {
+	iter := bc.Iterator()
+	for iter.Next() {
+		for _, tx := range iter.Block().Transactions {
+			for _, in := range tx.Inputs {
+				if bytes.Equal(in.ID, htlcTxID) && in.Out == outIdx && len(in.Preimage) > 0 {
+					return in.Preimage, nil
+				}
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("error while iterating blocks: %w", err)
+	}
+
+	return nil, ErrorHTLCNotClaimed
+}
+
+func (tx *Transaction) verifyHTLC(inIdx int, prevOut *TxOutput) bool {
+	in := &tx.Inputs[inIdx]
+	contract := prevOut.HTLC
+	if contract == nil {
+		return false
+	}
+
+	if !bytes.Equal(contract.Hash(), prevOut.PubKeyHash) {
+		return false
+	}
+
+	owner := contract.Sender
+	if len(in.Preimage) > 0 {
+		secretHash := sha256.Sum256(in.Preimage)
+		if !bytes.Equal(secretHash[:], contract.SecretHash) {
+			return false
+		}
+		owner = contract.Recipient
+	} else if !contract.refundable(tx.LockTime) {
+		return false
+	}
+
+	return in.UsesKey(owner) && tx.verifySignature(inIdx, prevOut, in.PubKey, in.Signature)
+}
@@ -0,0 +1,96 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+)
+
+func TestAtomicSwap(t *testing.T) {
+	alice, bob := newTestWallet(t), newTestWallet(t)
+
+	// alice holds coins on chain a, bob on chain b
+	chainA := newTestChain(t, alice)
+	chainB := newTestChain(t, bob)
+
+	preimage := []byte("the secret only alice knows")
+	secretHash := sha256.Sum256(preimage)
+
+	// alice locks 5 to bob on chain a, bob locks 7 to alice on chain b with
+	// the same secret hash and an earlier timeout
+	lockA, err := NewHTLCTransaction(alice, bob, 5, secretHash[:], 20, NewUTXOSet(chainA))
+	if err != nil {
+		t.Fatalf("NewHTLCTransaction a: %v", err)
+	}
+	mineTestBlock(t, chainA, alice, lockA)
+
+	lockB, err := NewHTLCTransaction(bob, alice, 7, secretHash[:], 10, NewUTXOSet(chainB))
+	if err != nil {
+		t.Fatalf("NewHTLCTransaction b: %v", err)
+	}
+	mineTestBlock(t, chainB, bob, lockB)
+
+	// bob can't take his coins back before the timeout
+	refundB, err := NewHTLCRefundTransaction(lockB.ID, 0, NewUTXOSet(chainB))
+	if err != nil {
+		t.Fatalf("NewHTLCRefundTransaction: %v", err)
+	}
+	if _, err := chainB.MineBlock([]*Transaction{refundB}); !errors.Is(err, ErrorTxNotFinal) {
+		t.Fatalf("early refund: got %v, want %v", err, ErrorTxNotFinal)
+	}
+
+	// a wrong preimage does not open the contract
+	if _, err := NewHTLCClaimTransaction(lockB.ID, 0, []byte("guess"), NewUTXOSet(chainB)); !errors.Is(err, ErrorTxCreateFailed) {
+		t.Fatalf("claim with wrong preimage: got %v, want %v", err, ErrorTxCreateFailed)
+	}
+
+	// alice claims on chain b, revealing the secret
+	if _, err := chainB.FindHTLCSecret(lockB.ID, 0); !errors.Is(err, ErrorHTLCNotClaimed) {
+		t.Fatalf("FindHTLCSecret before claim: got %v, want %v", err, ErrorHTLCNotClaimed)
+	}
+	claimB, err := NewHTLCClaimTransaction(lockB.ID, 0, preimage, NewUTXOSet(chainB))
+	if err != nil {
+		t.Fatalf("NewHTLCClaimTransaction b: %v", err)
+	}
+	mineTestBlock(t, chainB, bob, claimB)
+
+	// bob learns it from chain b and claims on chain a
+	secret, err := chainB.FindHTLCSecret(lockB.ID, 0)
+	if err != nil {
+		t.Fatalf("FindHTLCSecret: %v", err)
+	}
+	if !bytes.Equal(secret, preimage) {
+		t.Fatalf("FindHTLCSecret = %q, want %q", secret, preimage)
+	}
+	claimA, err := NewHTLCClaimTransaction(lockA.ID, 0, secret, NewUTXOSet(chainA))
+	if err != nil {
+		t.Fatalf("NewHTLCClaimTransaction a: %v", err)
+	}
+	mineTestBlock(t, chainA, alice, claimA)
+
+	// genesis and every block mined pay a reward of 20
+	for _, tc := range []struct {
+		name    string
+		chain   *BlockChain
+		address string
+		want    int
+	}{
+		{"alice on a", chainA, alice, 3*minerReward - 5},
+		{"bob on a", chainA, bob, 5},
+		{"bob on b", chainB, bob, 3*minerReward - 7},
+		{"alice on b", chainB, alice, 7},
+	} {
+		if got := balance(t, tc.chain, tc.address); got != tc.want {
+			t.Errorf("balance of %s = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+
+	// the contract is spent, neither a second claim nor a refund gets in
+	if _, err := chainA.MineBlock([]*Transaction{claimA}); !errors.Is(err, ErrorTxInvalid) {
+		t.Fatalf("second claim: got %v, want %v", err, ErrorTxInvalid)
+	}
+	if _, err := chainB.MineBlock([]*Transaction{refundB}); !errors.Is(err, ErrorTxInvalid) {
+		t.Fatalf("refund after claim: got %v, want %v", err, ErrorTxInvalid)
+	}
+}
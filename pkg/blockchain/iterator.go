@@ -1,42 +1,218 @@
 package blockchain
 
 import (
+	"context"
 	"fmt"
-	"log"
+)
+
+// Direction is the order an Iterator visits blocks in
+type Direction int
 
-	"github.com/dgraph-io/badger"
+const (
+	// Backward follows the previous hashes, from the tip down by default
+	Backward Direction = iota
+	// Forward goes up the main chain, from genesis by default
+	Forward
 )
 
+type IteratorOpt func(*Iterator)
+
+// WithDirection sets the order blocks are visited in, Backward by default
+func WithDirection(d Direction) IteratorOpt {
+	return func(it *Iterator) {
+		it.direction = d
+	}
+}
+
+// WithStart starts at the block with the given hash instead of the tip or
+// genesis. Going forward the block must be on the main chain.
+func WithStart(hash []byte) IteratorOpt {
+	return func(it *Iterator) {
+		it.start = hash
+	}
+}
+
+// WithHeights only visits the blocks from height from to height to, both
+// included. A negative bound leaves that side open.
+func WithHeights(from, to int) IteratorOpt {
+	return func(it *Iterator) {
+		it.from = from
+		it.to = to
+	}
+}
+
+// Iterator visits the blocks of a chain. Call Next until it returns false,
+// then Err tells whether all blocks were visited:
+//
+//	it := chain.Iterator()
+//	for it.Next() {
+//		block := it.Block()
+//	}
+//	if err := it.Err(); err != nil {
+//	}
 type Iterator struct {
-	Database    *badger.DB
-	CurrentHash []byte
+	bc  *BlockChain
+	ctx context.Context
+
+	direction Direction
+	start     []byte
+	from, to  int
+
+	started   bool
+	done      bool
+	tipHeight int
+	block     *Block
+	err       error
 }
 
-func (iter *Iterator) Next() *Block {
-	var block *Block
+// Iterator walks the chain from the tip down unless options say otherwise
+func (bc *BlockChain) Iterator(opts ...IteratorOpt) *Iterator {
+	return bc.IteratorContext(context.Background(), opts...)
+}
+
+// IteratorContext returns an iterator which stops with ctx.Err() once ctx is done
+func (bc *BlockChain) IteratorContext(ctx context.Context, opts ...IteratorOpt) *Iterator {
+	it := &Iterator{
+		bc:   bc,
+		ctx:  ctx,
+		from: -1,
+		to:   -1,
+	}
+	for _, opt := range opts {
+		opt(it)
+	}
+
+	return it
+}
+
+// Next moves to the next block. It returns false when there is none left
+// or on error.
+func (it *Iterator) Next() bool {
+	if it.err != nil || it.done {
+		return false
+	}
+
+	var next []byte
+	if !it.started {
+		it.started = true
+		next, it.err = it.first()
+	} else {
+		next, it.err = it.after(it.block)
+	}
 
-	err := iter.Database.View(func(txn *badger.Txn) error {
-		item, err := txn.Get(iter.CurrentHash)
+	for it.err == nil && next != nil {
+		if err := it.ctx.Err(); err != nil {
+			it.err = err
+			break
+		}
+
+		block, err := it.bc.GetBlock(next)
 		if err != nil {
-			return fmt.Errorf("error while getting last hash: %w", err)
+			it.err = err
+			break
+		}
+
+		// going down from a given start, blocks above the range are skipped
+		if it.direction == Backward && it.to >= 0 && block.Height > it.to {
+			next, it.err = it.after(block)
+			continue
 		}
+		if !it.inRange(block.Height) {
+			break
+		}
+
+		it.block = block
+		return true
+	}
+
+	it.block = nil
+	it.done = true
+
+	return false
+}
+
+// Block returns the block Next moved to
+func (it *Iterator) Block() *Block {
+	return it.block
+}
 
-		return item.Value(func(val []byte) error {
-			block = Deserialize(val)
+// Err returns the error which stopped the iteration, if any
+func (it *Iterator) Err() error {
+	return it.err
+}
 
-			return nil
-		})
-	})
+// first returns the hash of the first block to visit
+func (it *Iterator) first() ([]byte, error) {
+	lastHash := it.bc.LastHash()
+	tip, err := it.bc.GetHeader(lastHash)
 	if err != nil {
-		log.Panic(err)
+		return nil, err
+	}
+	it.tipHeight = tip.Height
+
+	if it.start != nil {
+		if it.direction == Forward {
+			header, err := it.bc.GetHeader(it.start)
+			if err != nil {
+				return nil, err
+			}
+			hash, err := it.bc.BlockHash(header.Height)
+			if err != nil || string(hash) != string(it.start) {
+				return nil, fmt.Errorf("block %x is not on the main chain", it.start)
+			}
+			// blocks below the range are skipped
+			if header.Height < it.from {
+				return it.hashAt(it.from)
+			}
+		}
+		return it.start, nil
+	}
+
+	if it.direction == Forward {
+		height := 0
+		if it.from > 0 {
+			height = it.from
+		}
+		return it.hashAt(height)
+	}
+
+	if it.to >= 0 && it.to < it.tipHeight {
+		return it.hashAt(it.to)
+	}
+
+	return lastHash, nil
+}
+
+// after returns the hash of the block visited after block
+func (it *Iterator) after(block *Block) ([]byte, error) {
+	if it.direction == Forward {
+		return it.hashAt(block.Height + 1)
 	}
 
-	iter.CurrentHash = block.PrevHash
+	// genesis has no previous hash
+	if len(block.PrevHash) == 0 {
+		return nil, nil
+	}
 
-	return block
+	return block.PrevHash, nil
 }
 
-func (iter *Iterator) HasNext() bool {
-	// Genesis' hash is empty
-	return len(iter.CurrentHash) > 0
+// hashAt returns the hash of the main chain block at height, nil above the
+// tip the iteration started with
+func (it *Iterator) hashAt(height int) ([]byte, error) {
+	if height > it.tipHeight {
+		return nil, nil
+	}
+
+	return it.bc.BlockHash(height)
+}
+
+// inRange reports whether the block at height is within the bounds, once a
+// block is past them there is nothing left to visit
+func (it *Iterator) inRange(height int) bool {
+	if it.from >= 0 && height < it.from {
+		return false
+	}
+
+	return it.to < 0 || height <= it.to
 }
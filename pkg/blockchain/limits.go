@@ -0,0 +1,70 @@
+package blockchain
+
+import (
+	"fmt"
+
+	"blockchain/pkg/chaincfg"
+)
+
+// witnessScale is how many times more bytes outside of the witness weigh
+const witnessScale = 4
+
+// Weight is the size of the transaction where every byte outside of the
+// witness counts four times, so signatures take less room in blocks
+func (tx *Transaction) Weight() int {
+	base := len(tx.encode(false))
+	total := len(tx.encode(true))
+
+	return base*(witnessScale-1) + total
+}
+
+// SigOps is the number of signature checks spending the inputs may take,
+// one per key of a multisig redeem script and one for any other input
+func (tx *Transaction) SigOps() int {
+	if tx.IsCoinbase() {
+		return 0
+	}
+
+	sigOps := 0
+	for _, in := range tx.Inputs {
+		if len(in.Redeem) > 0 {
+			sigOps += len(in.Signatures)
+			continue
+		}
+		sigOps++
+	}
+
+	return sigOps
+}
+
+// Weight returns the total weight of the transactions of the block
+func (b *Block) Weight() int {
+	weight := 0
+	for _, tx := range b.Transactions {
+		weight += tx.Weight()
+	}
+
+	return weight
+}
+
+// SigOps returns the total signature checks of the transactions of the block
+func (b *Block) SigOps() int {
+	sigOps := 0
+	for _, tx := range b.Transactions {
+		sigOps += tx.SigOps()
+	}
+
+	return sigOps
+}
+
+// verifyLimits checks the block against the size limits of the network
+func (b *Block) verifyLimits(params *chaincfg.Params) error {
+	if weight := b.Weight(); weight > params.MaxBlockWeight {
+		return fmt.Errorf("%w: weight %d, limit %d", ErrorBlkTooLarge, weight, params.MaxBlockWeight)
+	}
+	if sigOps := b.SigOps(); sigOps > params.MaxBlockSigOps {
+		return fmt.Errorf("%w: %d signature operations, limit %d", ErrorBlkTooManySigOps, sigOps, params.MaxBlockSigOps)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package blockchain
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"blockchain/pkg/crypto"
+	"blockchain/pkg/wallet"
+)
+
+// TestMain runs the tests in a scratch directory, wallets are stored
+// relative to the working directory
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "blockchain-test")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	code := func() int {
+		defer os.RemoveAll(dir)
+
+		if err := os.Chdir(dir); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if err := os.MkdirAll("tmp/wallets", 0755); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		return m.Run()
+	}()
+	os.Exit(code)
+}
+
+func newTestWallet(t *testing.T) string {
+	t.Helper()
+
+	address, err := wallet.CreateWallet(crypto.KeyECDSA)
+	if err != nil {
+		t.Fatalf("CreateWallet: %v", err)
+	}
+
+	return address
+}
+
+// newTestChain creates a chain in a directory of its own, paying the genesis reward to address
+func newTestChain(t *testing.T, address string) *BlockChain {
+	t.Helper()
+
+	chain, err := InitBlockChain(address, WithDataDir(t.TempDir()))
+	if err != nil {
+		t.Fatalf("InitBlockChain: %v", err)
+	}
+	t.Cleanup(chain.Close)
+
+	return chain
+}
+
+// mineTestBlock mines txs into a block rewarding miner
+func mineTestBlock(t *testing.T, chain *BlockChain, miner string, txs ...*Transaction) *Block {
+	t.Helper()
+
+	cbTx, err := CoinbaseTx(miner, "")
+	if err != nil {
+		t.Fatalf("CoinbaseTx: %v", err)
+	}
+
+	block, err := chain.MineBlock(append(txs, cbTx))
+	if err != nil {
+		t.Fatalf("MineBlock: %v", err)
+	}
+
+	return block
+}
+
+func balance(t *testing.T, chain *BlockChain, address string) int {
+	t.Helper()
+
+	pubKeyHash, err := wallet.PubKeyHashFromAddress(address)
+	if err != nil {
+		t.Fatalf("PubKeyHashFromAddress: %v", err)
+	}
+	outs, err := NewUTXOSet(chain).FindUTXOs(pubKeyHash)
+	if err != nil {
+		t.Fatalf("FindUTXOs: %v", err)
+	}
+
+	total := 0
+	for _, out := range *outs {
+		total += out.Value
+	}
+
+	return total
+}
@@ -0,0 +1,119 @@
+package blockchain
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// medianTimeBlocks is the number of blocks the median time past is taken over
+	medianTimeBlocks = 11
+	// MaxFutureBlockTime is how many seconds block timestamps may be ahead of the adjusted time
+	MaxFutureBlockTime = 2 * 60 * 60
+
+	maxTimeSamples = 200
+	// maxTimeOffset is the largest correction peers may make to the local clock
+	maxTimeOffset = 70 * time.Minute
+)
+
+// TimeSource gives the network adjusted time block timestamps are checked against
+type TimeSource interface {
+	AdjustedTime() time.Time
+}
+
+// MedianTime corrects the local clock by the median of the clock offsets
+// reported by peers. Corrections above 70 minutes are ignored, the local
+// clock is more likely right than a network of peers that far off.
+type MedianTime struct {
+	mu      sync.Mutex
+	offsets map[string]time.Duration
+	// peers lists the peers with an offset, oldest sample first
+	peers []string
+}
+
+func NewMedianTime() *MedianTime {
+	return &MedianTime{offsets: make(map[string]time.Duration)}
+}
+
+// AddTimeSample records the clock of peer, only its first sample counts
+func (m *MedianTime) AddTimeSample(peer string, peerTime time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.offsets[peer]; ok {
+		return
+	}
+	if len(m.peers) == maxTimeSamples {
+		delete(m.offsets, m.peers[0])
+		m.peers = m.peers[1:]
+	}
+
+	m.offsets[peer] = time.Until(peerTime).Truncate(time.Second)
+	m.peers = append(m.peers, peer)
+}
+
+// Offset returns the correction applied to the local clock
+func (m *MedianTime) Offset() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.offsets) == 0 {
+		return 0
+	}
+
+	offsets := make([]time.Duration, 0, len(m.offsets))
+	for _, offset := range m.offsets {
+		offsets = append(offsets, offset)
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+
+	median := offsets[len(offsets)/2]
+	if median > maxTimeOffset || median < -maxTimeOffset {
+		return 0
+	}
+
+	return median
+}
+
+func (m *MedianTime) AdjustedTime() time.Time {
+	return time.Now().Add(m.Offset())
+}
+
+// MedianTimePast returns the median timestamp of the block with the given
+// hash and the 10 blocks before it. Lock times are checked against it,
+// and blocks on top of it must have a later timestamp.
+func (bc *BlockChain) MedianTimePast(hash []byte) (int64, error) {
+	timestamps := make([]int64, 0, medianTimeBlocks)
+
+	for len(timestamps) < medianTimeBlocks {
+		header, err := bc.GetHeader(hash)
+		if err != nil {
+			return 0, err
+		}
+		timestamps = append(timestamps, header.Timestamp)
+
+		if header.Height == 0 {
+			break
+		}
+		hash = header.PrevHash
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+
+	return timestamps[len(timestamps)/2], nil
+}
+
+// verifyTimestamp checks the timestamp of a block against mtp, the median
+// time past of its parent, and against the adjusted time
+func (bc *BlockChain) verifyTimestamp(block *Block, mtp int64) error {
+	if block.Timestamp <= mtp {
+		return fmt.Errorf("%w: %d, median time past %d", ErrorBlkTimeTooOld, block.Timestamp, mtp)
+	}
+
+	if limit := bc.timeSource.AdjustedTime().Unix() + MaxFutureBlockTime; block.Timestamp > limit {
+		return fmt.Errorf("%w: %d, limit %d", ErrorBlkTimeTooNew, block.Timestamp, limit)
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package blockchain
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMedianTime(t *testing.T) {
+	mt := NewMedianTime()
+	if offset := mt.Offset(); offset != 0 {
+		t.Fatalf("offset without samples = %s, want 0", offset)
+	}
+
+	// the second sample of a peer is ignored
+	now := time.Now()
+	for i, offset := range []time.Duration{-time.Minute, 10 * time.Minute, 5 * time.Minute} {
+		mt.AddTimeSample(fmt.Sprintf("peer%d", i), now.Add(offset))
+	}
+	mt.AddTimeSample("peer0", now.Add(time.Hour))
+	if offset := mt.Offset(); offset < 4*time.Minute || offset > 5*time.Minute {
+		t.Errorf("offset = %s, want the median of about 5m", offset)
+	}
+
+	// peers further off than maxTimeOffset leave the local clock alone
+	for i := 3; i < 8; i++ {
+		mt.AddTimeSample(fmt.Sprintf("peer%d", i), now.Add(2*time.Hour))
+	}
+	if offset := mt.Offset(); offset != 0 {
+		t.Errorf("offset = %s, want 0", offset)
+	}
+
+	chain := newTestChain(t, newTestWallet(t))
+	if _, ok := chain.TimeSource().(*MedianTime); !ok {
+		t.Errorf("time source of the chain is a %T, want a *MedianTime", chain.TimeSource())
+	}
+}
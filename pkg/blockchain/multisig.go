@@ -0,0 +1,154 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"blockchain/pkg/crypto"
+	"blockchain/pkg/wallet"
+)
+
+// NewMultisigTransaction builds an unsigned spend of the outputs locked to script.
+// The returned transaction is passed between the key holders, each of them adding
+// a signature with BlockChain.SignTransaction, until enough signatures are present.
+func NewMultisigTransaction(script *wallet.MultisigScript, to string, amount int, utxo UTXOSource, opts ...TxOpt) (*Transaction, error) {
+	var (
+		inputs  []TxInput
+		outputs []TxOutput
+	)
+
+	redeem := script.Serialize()
+	scriptHash := crypto.HashPublicKey(redeem)
+
+	acc, validOutputs, err := utxo.FindSpendableUTXOs(scriptHash, amount)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrorTxCreateFailed, err)
+	}
+	if acc < amount {
+		return nil, fmt.Errorf("%w: not enough funds", ErrorTxCreateFailed)
+	}
+
+	for txId, outs := range validOutputs {
+		txID, err := hex.DecodeString(txId)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrorTxCreateFailed, err)
+		}
+
+		for _, out := range outs {
+			inputs = append(inputs, TxInput{
+				ID:      txID,
+				Out:     out,
+				Redeem:  redeem,
+				Witness: Witness{Signatures: make([][]byte, len(script.PubKeys))},
+			})
+		}
+	}
+
+	out := NewTXOutput(amount, to)
+	outputs = append(outputs, *out)
+
+	if acc > amount {
+		outputs = append(outputs, TxOutput{
+			Value:      acc - amount,
+			PubKeyHash: scriptHash,
+			Type:       OutputMultisig,
+		})
+	}
+
+	tx := &Transaction{
+		Inputs:  inputs,
+		Outputs: outputs,
+	}
+	for _, opt := range opts {
+		opt(tx)
+	}
+	if err := tx.SetID(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrorTxCreateFailed, err)
+	}
+
+	return tx, nil
+}
+
+// SignWithWallets adds the signature of every wallet to the inputs it can sign
+func (bc *BlockChain) SignWithWallets(tx *Transaction, wallets ...*wallet.Wallet) error {
+	for _, w := range wallets {
+		if err := bc.SignTransaction(tx, w.PrivateKey); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CombineSignatures merges the signatures of another partially signed copy of tx
+func (tx *Transaction) CombineSignatures(other *Transaction) error {
+	if !bytes.Equal(tx.ID, other.ID) || len(tx.Inputs) != len(other.Inputs) {
+		return errors.New("cannot combine signatures of different transactions")
+	}
+
+	for i := range tx.Inputs {
+		in, otherIn := &tx.Inputs[i], &other.Inputs[i]
+
+		if in.Signature == nil {
+			in.Signature = otherIn.Signature
+		}
+
+		if len(otherIn.Signatures) == 0 {
+			continue
+		}
+		if len(in.Signatures) != len(otherIn.Signatures) {
+			return fmt.Errorf("input %d has mismatched signature slots", i)
+		}
+		for j, sig := range otherIn.Signatures {
+			if in.Signatures[j] == nil {
+				in.Signatures[j] = sig
+			}
+		}
+	}
+
+	return nil
+}
+
+// multisigSlot returns the signature slot of pubKey, or -1 if it is not a cosigner
+func (in *TxInput) multisigSlot(pubKey []byte) (int, error) {
+	script, err := wallet.ParseMultisigScript(in.Redeem)
+	if err != nil {
+		return -1, err
+	}
+
+	if len(in.Signatures) == 0 {
+		in.Signatures = make([][]byte, len(script.PubKeys))
+	}
+	if len(in.Signatures) != len(script.PubKeys) {
+		return -1, errors.New("signature slots do not match multisig script")
+	}
+
+	return script.KeyIndex(pubKey), nil
+}
+
+func (tx *Transaction) verifyMultisig(inIdx int, prevOut *TxOutput) bool {
+	in := &tx.Inputs[inIdx]
+	if !bytes.Equal(crypto.HashPublicKey(in.Redeem), prevOut.PubKeyHash) {
+		return false
+	}
+
+	script, err := wallet.ParseMultisigScript(in.Redeem)
+	if err != nil || len(in.Signatures) != len(script.PubKeys) {
+		return false
+	}
+
+	valid := 0
+	for i, sig := range in.Signatures {
+		if sig == nil {
+			continue
+		}
+		if !tx.verifySignature(inIdx, prevOut, script.PubKeys[i], sig) {
+			return false
+		}
+		valid++
+	}
+
+	return valid >= script.Required
+}
@@ -0,0 +1,111 @@
+package blockchain
+
+import (
+	"errors"
+	"testing"
+
+	"blockchain/pkg/wallet"
+)
+
+func TestMultisigSpend(t *testing.T) {
+	alice, bob, carol, dave := newTestWallet(t), newTestWallet(t), newTestWallet(t), newTestWallet(t)
+	chain := newTestChain(t, alice)
+	utxo := NewUTXOSet(chain)
+
+	wallets := make([]*wallet.Wallet, 0, 3)
+	pubKeys := make([][]byte, 0, 3)
+	for _, address := range []string{alice, bob, carol} {
+		w, err := wallet.GetWallet(address)
+		if err != nil {
+			t.Fatalf("GetWallet: %v", err)
+		}
+		wallets = append(wallets, w)
+		pubKeys = append(pubKeys, w.PublicKeyBytes())
+	}
+	script, err := wallet.NewMultisigScript(2, pubKeys)
+	if err != nil {
+		t.Fatalf("NewMultisigScript: %v", err)
+	}
+
+	fund, err := NewTransaction(alice, script.Address(), 10, utxo)
+	if err != nil {
+		t.Fatalf("NewTransaction: %v", err)
+	}
+	mineTestBlock(t, chain, alice, fund)
+
+	unsigned, err := NewMultisigTransaction(script, dave, 4, utxo)
+	if err != nil {
+		t.Fatalf("NewMultisigTransaction: %v", err)
+	}
+
+	// each cosigner signs a copy of their own, as passed around in files
+	copies := make([]*Transaction, 0, 2)
+	for _, w := range wallets[:2] {
+		data, err := unsigned.Serialize()
+		if err != nil {
+			t.Fatalf("Serialize: %v", err)
+		}
+		tx := &Transaction{}
+		if err := tx.Deserialize(data); err != nil {
+			t.Fatalf("Deserialize: %v", err)
+		}
+
+		prevTXs, err := FindPrevTransactions(tx, utxo)
+		if err != nil {
+			t.Fatalf("FindPrevTransactions: %v", err)
+		}
+		if err := tx.Sign(w.PrivateKey, prevTXs); err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+		copies = append(copies, tx)
+	}
+
+	if chain.VerifyTransaction(copies[0]) {
+		t.Fatal("VerifyTransaction accepted a spend with 1 of 2 signatures")
+	}
+
+	tx := copies[0]
+	if err := tx.CombineSignatures(copies[1]); err != nil {
+		t.Fatalf("CombineSignatures: %v", err)
+	}
+	if !chain.VerifyTransaction(tx) {
+		t.Fatal("VerifyTransaction rejected a spend with 2 of 2 signatures")
+	}
+	mineTestBlock(t, chain, alice, tx)
+
+	if got := balance(t, chain, dave); got != 4 {
+		t.Errorf("balance of dave = %d, want 4", got)
+	}
+}
+
+func TestSignMalformedTransaction(t *testing.T) {
+	alice := newTestWallet(t)
+	chain := newTestChain(t, alice)
+	utxo := NewUTXOSet(chain)
+
+	w, err := wallet.GetWallet(alice)
+	if err != nil {
+		t.Fatalf("GetWallet: %v", err)
+	}
+	tx, err := NewTransaction(alice, alice, 5, utxo)
+	if err != nil {
+		t.Fatalf("NewTransaction: %v", err)
+	}
+	prevTXs, err := FindPrevTransactions(tx, utxo)
+	if err != nil {
+		t.Fatalf("FindPrevTransactions: %v", err)
+	}
+
+	// a hostile file may spend outputs its previous transactions don't have
+	for _, out := range []int{-1, 100} {
+		tx.Inputs[0].Out = out
+		if err := tx.Sign(w.PrivateKey, prevTXs); !errors.Is(err, ErrorTxSignFailed) {
+			t.Errorf("output %d: got %v, want %v", out, err, ErrorTxSignFailed)
+		}
+	}
+
+	tx.Inputs[0].Out = 0
+	if err := tx.Sign(w.PrivateKey, map[string]*Transaction{}); !errors.Is(err, ErrorTxSignFailed) {
+		t.Errorf("unknown previous transaction: got %v, want %v", err, ErrorTxSignFailed)
+	}
+}
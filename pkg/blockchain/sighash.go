@@ -0,0 +1,120 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"blockchain/pkg/crypto"
+)
+
+// SigHashType selects the parts of a transaction a signature commits to.
+// It is appended as the last byte of every input signature.
+type SigHashType byte
+
+const (
+	// SigHashAll commits to all inputs and outputs
+	SigHashAll SigHashType = 0x01
+	// SigHashNone commits to the inputs only, anyone may change the outputs
+	SigHashNone SigHashType = 0x02
+	// SigHashSingle commits to the inputs and the output with the same index as the signed input
+	SigHashSingle SigHashType = 0x03
+	// SigHashAnyoneCanPay combined with one of the above commits to the signed input only,
+	// so others can add their own inputs
+	SigHashAnyoneCanPay SigHashType = 0x80
+
+	sigHashMask = 0x1f
+)
+
+func (t SigHashType) base() SigHashType {
+	return t & sigHashMask
+}
+
+func (t SigHashType) IsValid() bool {
+	base := t.base()
+	if t&^(sigHashMask|SigHashAnyoneCanPay) != 0 {
+		return false
+	}
+
+	return base == SigHashAll || base == SigHashNone || base == SigHashSingle
+}
+
+func (t SigHashType) String() string {
+	var name string
+	switch t.base() {
+	case SigHashAll:
+		name = "ALL"
+	case SigHashNone:
+		name = "NONE"
+	case SigHashSingle:
+		name = "SINGLE"
+	default:
+		return fmt.Sprintf("UNKNOWN(%#x)", byte(t))
+	}
+
+	if t&SigHashAnyoneCanPay != 0 {
+		name += "|ANYONECANPAY"
+	}
+
+	return name
+}
+
+// SignatureHash returns the digest signed for input inIdx spending prevOut
+func (tx *Transaction) SignatureHash(inIdx int, prevOut *TxOutput, hashType SigHashType) ([]byte, error) {
+	if inIdx < 0 || inIdx >= len(tx.Inputs) {
+		return nil, fmt.Errorf("input %d out of range", inIdx)
+	}
+	if !hashType.IsValid() {
+		return nil, fmt.Errorf("invalid signature hash type %#x", byte(hashType))
+	}
+
+	txCopy := tx.TrimmedCopy()
+	txCopy.ID = nil
+	txCopy.Inputs[inIdx].PubKey = prevOut.PubKeyHash
+
+	switch hashType.base() {
+	case SigHashNone:
+		txCopy.Outputs = nil
+	case SigHashSingle:
+		if inIdx >= len(txCopy.Outputs) {
+			return nil, fmt.Errorf("no output matching input %d for %s", inIdx, hashType)
+		}
+		txCopy.Outputs = txCopy.Outputs[inIdx : inIdx+1]
+	}
+
+	if hashType&SigHashAnyoneCanPay != 0 {
+		txCopy.Inputs = txCopy.Inputs[inIdx : inIdx+1]
+	}
+
+	hash := sha256.Sum256(append(txCopy.encode(false), byte(hashType)))
+
+	return hash[:], nil
+}
+
+func (tx *Transaction) signInput(inIdx int, prevOut *TxOutput, privKey crypto.Signer, hashType SigHashType) ([]byte, error) {
+	hash, err := tx.SignatureHash(inIdx, prevOut, hashType)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := privKey.Sign(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(signature, byte(hashType)), nil
+}
+
+// verifySignature checks a signature of input inIdx, honoring the hash type it carries
+func (tx *Transaction) verifySignature(inIdx int, prevOut *TxOutput, pubKey, signature []byte) bool {
+	if len(signature) < 2 {
+		return false
+	}
+
+	hashType := SigHashType(signature[len(signature)-1])
+	hash, err := tx.SignatureHash(inIdx, prevOut, hashType)
+	if err != nil {
+		return false
+	}
+
+	return crypto.Verify(pubKey, hash, signature[:len(signature)-1])
+}
@@ -0,0 +1,161 @@
+package blockchain
+
+import (
+	"testing"
+
+	"blockchain/pkg/crypto"
+)
+
+// sighashTx returns a transaction with two inputs and two outputs, its
+// first input spending prevOut of key
+func sighashTx(key crypto.Signer) (*Transaction, *TxOutput) {
+	pubKey := key.Public().Bytes()
+	prevOut := &TxOutput{Value: 10, PubKeyHash: crypto.HashPublicKey(pubKey)}
+
+	tx := &Transaction{
+		Inputs: []TxInput{
+			{ID: []byte("previous transaction 0"), Out: 0, PubKey: pubKey},
+			{ID: []byte("previous transaction 1"), Out: 1, PubKey: []byte("someone else")},
+		},
+		Outputs: []TxOutput{
+			{Value: 4, PubKeyHash: []byte("recipient 0")},
+			{Value: 5, PubKeyHash: []byte("recipient 1")},
+		},
+	}
+
+	return tx, prevOut
+}
+
+func TestSignatureHashCommitments(t *testing.T) {
+	key, err := crypto.GenerateKey(crypto.KeyECDSA)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	const (
+		all    = SigHashAll
+		none   = SigHashNone
+		single = SigHashSingle
+		acp    = SigHashAnyoneCanPay
+	)
+	hashTypes := []SigHashType{all, none, single, all | acp, none | acp, single | acp}
+
+	// committed lists the hash types whose signatures of input 0 the mutation breaks
+	mutations := []struct {
+		name      string
+		mutate    func(tx *Transaction)
+		committed []SigHashType
+	}{
+		{
+			name:      "own input outpoint",
+			mutate:    func(tx *Transaction) { tx.Inputs[0].Out = 2 },
+			committed: hashTypes,
+		},
+		{
+			name:      "lock time",
+			mutate:    func(tx *Transaction) { tx.LockTime = 100 },
+			committed: hashTypes,
+		},
+		{
+			name:      "other input outpoint",
+			mutate:    func(tx *Transaction) { tx.Inputs[1].Out = 3 },
+			committed: []SigHashType{all, none, single},
+		},
+		{
+			name: "input added",
+			mutate: func(tx *Transaction) {
+				tx.Inputs = append(tx.Inputs, TxInput{ID: []byte("previous transaction 2")})
+			},
+			committed: []SigHashType{all, none, single},
+		},
+		{
+			name:      "input removed",
+			mutate:    func(tx *Transaction) { tx.Inputs = tx.Inputs[:1] },
+			committed: []SigHashType{all, none, single},
+		},
+		{
+			name:      "output of the same index",
+			mutate:    func(tx *Transaction) { tx.Outputs[0].Value = 1 },
+			committed: []SigHashType{all, single, all | acp, single | acp},
+		},
+		{
+			name:      "other output",
+			mutate:    func(tx *Transaction) { tx.Outputs[1].PubKeyHash = []byte("thief") },
+			committed: []SigHashType{all, all | acp},
+		},
+		{
+			name: "output added",
+			mutate: func(tx *Transaction) {
+				tx.Outputs = append(tx.Outputs, TxOutput{Value: 1, PubKeyHash: []byte("thief")})
+			},
+			committed: []SigHashType{all, all | acp},
+		},
+		{
+			name:      "other input witness",
+			mutate:    func(tx *Transaction) { tx.Inputs[1].Signature = []byte("signature") },
+			committed: nil,
+		},
+	}
+
+	for _, hashType := range hashTypes {
+		for _, m := range mutations {
+			t.Run(hashType.String()+"/"+m.name, func(t *testing.T) {
+				tx, prevOut := sighashTx(key)
+				signature, err := tx.signInput(0, prevOut, key, hashType)
+				if err != nil {
+					t.Fatalf("signInput: %v", err)
+				}
+				if !tx.verifySignature(0, prevOut, tx.Inputs[0].PubKey, signature) {
+					t.Fatal("signature does not verify before the mutation")
+				}
+
+				m.mutate(tx)
+
+				committed := false
+				for _, ht := range m.committed {
+					committed = committed || ht == hashType
+				}
+				if got := tx.verifySignature(0, prevOut, tx.Inputs[0].PubKey, signature); got == committed {
+					t.Errorf("signature valid after mutation = %v, want %v", got, !committed)
+				}
+			})
+		}
+	}
+}
+
+func TestSignatureHashSingleWithoutOutput(t *testing.T) {
+	key, err := crypto.GenerateKey(crypto.KeyECDSA)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	tx, prevOut := sighashTx(key)
+	tx.Outputs = tx.Outputs[:1]
+	for _, hashType := range []SigHashType{SigHashSingle, SigHashSingle | SigHashAnyoneCanPay} {
+		if _, err := tx.SignatureHash(1, prevOut, hashType); err == nil {
+			t.Errorf("%s: signed input 1 without output 1", hashType)
+		}
+	}
+}
+
+func TestSigHashTypeIsValid(t *testing.T) {
+	for _, tc := range []struct {
+		hashType SigHashType
+		valid    bool
+	}{
+		{SigHashAll, true},
+		{SigHashNone, true},
+		{SigHashSingle, true},
+		{SigHashAll | SigHashAnyoneCanPay, true},
+		{SigHashNone | SigHashAnyoneCanPay, true},
+		{SigHashSingle | SigHashAnyoneCanPay, true},
+		{0, false},
+		{SigHashAnyoneCanPay, false},
+		{0x04, false},
+		{0x41, false},
+	} {
+		if got := tc.hashType.IsValid(); got != tc.valid {
+			t.Errorf("%s.IsValid() = %v, want %v", tc.hashType, got, tc.valid)
+		}
+	}
+}
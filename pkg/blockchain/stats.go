@@ -0,0 +1,133 @@
+package blockchain
+
+import (
+	"fmt"
+
+	"github.com/dgraph-io/badger"
+)
+
+// Stats summarizes the blocks of a chain between two heights
+type Stats struct {
+	FromHeight int `json:"from_height"`
+	ToHeight   int `json:"to_height"`
+
+	Blocks       int `json:"blocks"`
+	Transactions int `json:"transactions"`
+	// Issued is the value the coinbases of the range created
+	Issued int `json:"issued"`
+	// Supply is the value all coinbases up to ToHeight created
+	Supply int `json:"supply"`
+	// AvgBlockInterval is the mean number of seconds between blocks of the range
+	AvgBlockInterval float64 `json:"avg_block_interval"`
+	// Difficulty lists the difficulty of the first block of the range and every change after it
+	Difficulty []DifficultyChange `json:"difficulty"`
+
+	// UTXOs and UTXOValue describe the current UTXO set, whatever the range
+	UTXOs     int `json:"utxos"`
+	UTXOValue int `json:"utxo_value"`
+}
+
+// DifficultyChange is the difficulty from Height on
+type DifficultyChange struct {
+	Height     int `json:"height"`
+	Difficulty int `json:"difficulty"`
+}
+
+// Stats computes statistics over the blocks from height from to height to,
+// both included. A negative to stands for the chain tip.
+func (bc *BlockChain) Stats(from, to int) (*Stats, error) {
+	tip, err := bc.GetHeader(bc.LastHash())
+	if err != nil {
+		return nil, fmt.Errorf("error while getting last block: %w", err)
+	}
+	if to < 0 || to > tip.Height {
+		to = tip.Height
+	}
+	if from < 0 || from > to {
+		return nil, fmt.Errorf("invalid height range %d to %d, the chain tip is at %d", from, to, tip.Height)
+	}
+
+	stats := &Stats{FromHeight: from, ToHeight: to}
+
+	// blocks are walked from the tip down, the timestamps and difficulties
+	// of the range are collected to be put in height order afterwards
+	var (
+		first, last int64
+		difficulty  []DifficultyChange
+	)
+	iter := bc.Iterator(WithHeights(-1, to))
+	for iter.Next() {
+		block := iter.Block()
+
+		coinbase := block.Transactions[len(block.Transactions)-1]
+		issued := 0
+		for _, out := range coinbase.Outputs {
+			issued += out.Value
+		}
+		stats.Supply += issued
+
+		if block.Height < from {
+			continue
+		}
+
+		stats.Blocks++
+		stats.Transactions += len(block.Transactions)
+		stats.Issued += issued
+
+		if block.Height == to {
+			last = block.Timestamp
+		}
+		first = block.Timestamp
+
+		if n := len(difficulty); n > 0 && difficulty[n-1].Difficulty == block.Difficulty {
+			difficulty[n-1].Height = block.Height
+		} else {
+			difficulty = append(difficulty, DifficultyChange{Height: block.Height, Difficulty: block.Difficulty})
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("error while iterating blocks: %w", err)
+	}
+
+	if stats.Blocks > 1 {
+		stats.AvgBlockInterval = float64(last-first) / float64(stats.Blocks-1)
+	}
+	for i := len(difficulty) - 1; i >= 0; i-- {
+		stats.Difficulty = append(stats.Difficulty, difficulty[i])
+	}
+
+	stats.UTXOs, stats.UTXOValue, err = NewUTXOSet(bc).Totals()
+	if err != nil {
+		return nil, fmt.Errorf("error while reading utxo set: %w", err)
+	}
+
+	return stats, nil
+}
+
+// Totals returns the number and the total value of the unspent outputs
+func (u *UTXOSet) Totals() (int, int, error) {
+	count, value := 0, 0
+
+	err := u.database.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(utxoPrefix); it.ValidForPrefix(utxoPrefix); it.Next() {
+			var outs UnspentOutputs
+			if err := it.Item().Value(func(val []byte) error {
+				return outs.Deserialize(val)
+			}); err != nil {
+				return err
+			}
+
+			for _, out := range outs {
+				count++
+				value += out.Value
+			}
+		}
+
+		return nil
+	})
+
+	return count, value, err
+}
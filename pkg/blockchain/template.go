@@ -0,0 +1,118 @@
+package blockchain
+
+import (
+	"fmt"
+
+	"blockchain/pkg/consensus"
+	"blockchain/pkg/consensus/pow"
+)
+
+// BlockTemplate is the work handed to miners hashing outside of the node.
+// They search a nonce for Header, rolling its timestamp when they run out,
+// until Algorithm hashes its seal data below Target, then submit Solve's block.
+type BlockTemplate struct {
+	Header       consensus.Header
+	Coinbase     *Transaction
+	Transactions []*Transaction
+
+	// Target is big endian, a solved header hashes strictly below it
+	Target    []byte
+	Algorithm pow.Algorithm
+}
+
+// NewBlockTemplate prepares a block on top of the chain tip paying coinbase
+// and including transactions, which are verified first. Only proof of work
+// chains can hand out templates.
+func (bc *BlockChain) NewBlockTemplate(coinbase *Transaction, transactions []*Transaction) (*BlockTemplate, error) {
+	engine, ok := bc.engine.(*pow.ProofOfWork)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrorTemplateUnsupported, bc.params.Name)
+	}
+
+	if !coinbase.IsCoinbase() {
+		return nil, ErrorBlkCoinbaseInvalid
+	}
+
+	tip, err := bc.GetHeader(bc.LastHash())
+	if err != nil {
+		return nil, fmt.Errorf("error while getting last block: %w", err)
+	}
+
+	mtp, err := bc.MedianTimePast(tip.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting median time past: %w", err)
+	}
+	for _, tx := range transactions {
+		if !bc.VerifyTransaction(tx) {
+			return nil, fmt.Errorf("while verifying transaction %x: %w", tx.ID, ErrorTxInvalid)
+		}
+		if !tx.IsFinal(tip.Height+1, mtp) {
+			return nil, fmt.Errorf("while checking transaction %x: %w", tx.ID, ErrorTxNotFinal)
+		}
+	}
+
+	// the coinbase goes last, like in blocks mined by the node
+	txs := append(append([]*Transaction{}, transactions...), coinbase)
+	block := NewBlock(txs, tip.Hash, tip.Height+1)
+	if block.Timestamp <= mtp {
+		block.Timestamp = mtp + 1
+	}
+	if err := block.verifyLimits(bc.params); err != nil {
+		return nil, err
+	}
+	if err := engine.Prepare(bc, &block.Header); err != nil {
+		return nil, err
+	}
+
+	return &BlockTemplate{
+		Header:       block.Header,
+		Coinbase:     coinbase,
+		Transactions: transactions,
+		Target:       pow.Target(block.Difficulty).FillBytes(make([]byte, 32)),
+		Algorithm:    engine.Algorithm(),
+	}, nil
+}
+
+// WithCoinbase returns a copy of the template paying coinbase instead,
+// pools give every worker its own coinbase so they search different blocks
+func (t *BlockTemplate) WithCoinbase(coinbase *Transaction) (*BlockTemplate, error) {
+	if !coinbase.IsCoinbase() {
+		return nil, ErrorBlkCoinbaseInvalid
+	}
+
+	tmpl := *t
+	tmpl.Coinbase = coinbase
+	block := tmpl.Solve(t.Header.Timestamp, 0)
+	tmpl.Header.TxRoot = block.HashTransactions()
+	tmpl.Header.WitnessRoot = block.HashWitnesses()
+
+	return &tmpl, nil
+}
+
+// Solve returns the block of the template with the timestamp and nonce found by a miner
+func (t *BlockTemplate) Solve(timestamp int64, nonce int) *Block {
+	block := &Block{
+		Header:       t.Header,
+		Transactions: append(append([]*Transaction{}, t.Transactions...), t.Coinbase),
+	}
+	block.Timestamp = timestamp
+	block.Nonce = nonce
+	block.Hash = block.SealHash()
+
+	return block
+}
+
+// SubmitBlock validates a block solved outside of the node and adds it to the chain
+func (bc *BlockChain) SubmitBlock(block *Block) error {
+	if len(block.Transactions) == 0 || !block.Transactions[len(block.Transactions)-1].IsCoinbase() {
+		return fmt.Errorf("error while submitting block: %w", ErrorBlkCoinbaseInvalid)
+	}
+
+	for _, tx := range block.Transactions[:len(block.Transactions)-1] {
+		if tx.IsCoinbase() {
+			return fmt.Errorf("error while submitting block: %w", ErrorBlkCoinbaseInvalid)
+		}
+	}
+
+	return bc.AddBlock(block)
+}
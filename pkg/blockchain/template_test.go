@@ -0,0 +1,87 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"blockchain/pkg/wallet"
+)
+
+// coinbasePaying returns a coinbase paying value to address
+func coinbasePaying(t *testing.T, address string, value int) *Transaction {
+	t.Helper()
+
+	cbTx, err := CoinbaseTx(address, "")
+	if err != nil {
+		t.Fatalf("CoinbaseTx: %v", err)
+	}
+	cbTx.Outputs[0].Value = value
+	if err := cbTx.SetID(); err != nil {
+		t.Fatalf("SetID: %v", err)
+	}
+
+	return cbTx
+}
+
+func TestCoinbaseValue(t *testing.T) {
+	alice, bob := newTestWallet(t), newTestWallet(t)
+	chain := newTestChain(t, alice)
+	utxo := NewUTXOSet(chain)
+
+	// a submitted block can't pay its miner more than the reward
+	tmpl, err := chain.NewBlockTemplate(coinbasePaying(t, bob, minerReward+1), nil)
+	if err != nil {
+		t.Fatalf("NewBlockTemplate: %v", err)
+	}
+	block := tmpl.Solve(tmpl.Header.Timestamp, 0)
+	if err := chain.SealBlock(context.Background(), block); err != nil {
+		t.Fatalf("SealBlock: %v", err)
+	}
+	if err := chain.SubmitBlock(block); !errors.Is(err, ErrorBlkCoinbaseInvalid) {
+		t.Fatalf("submitted overpaying coinbase: got %v, want %v", err, ErrorBlkCoinbaseInvalid)
+	}
+
+	// a transaction leaving 3 of what it spends unpaid raises the limit by 3
+	tx, err := NewTransaction(alice, bob, 5, utxo)
+	if err != nil {
+		t.Fatalf("NewTransaction: %v", err)
+	}
+	tx.Outputs[1].Value -= 3
+	if err := tx.SetID(); err != nil {
+		t.Fatalf("SetID: %v", err)
+	}
+	w, err := wallet.GetWallet(alice)
+	if err != nil {
+		t.Fatalf("GetWallet: %v", err)
+	}
+	if err := chain.SignTransaction(tx, w.PrivateKey); err != nil {
+		t.Fatalf("SignTransaction: %v", err)
+	}
+
+	if _, err := chain.MineBlock([]*Transaction{tx, coinbasePaying(t, bob, minerReward+4)}); !errors.Is(err, ErrorBlkCoinbaseInvalid) {
+		t.Fatalf("coinbase above reward and fees: got %v, want %v", err, ErrorBlkCoinbaseInvalid)
+	}
+	if _, err := chain.MineBlock([]*Transaction{tx, coinbasePaying(t, bob, minerReward+3)}); err != nil {
+		t.Fatalf("coinbase of reward and fees: %v", err)
+	}
+	if got, want := balance(t, chain, bob), 5+minerReward+3; got != want {
+		t.Errorf("balance of bob = %d, want %d", got, want)
+	}
+
+	// nor can a transaction pay more than it spends
+	inflated, err := NewTransaction(alice, bob, 5, utxo)
+	if err != nil {
+		t.Fatalf("NewTransaction: %v", err)
+	}
+	inflated.Outputs[0].Value += 100
+	if err := inflated.SetID(); err != nil {
+		t.Fatalf("SetID: %v", err)
+	}
+	if err := chain.SignTransaction(inflated, w.PrivateKey); err != nil {
+		t.Fatalf("SignTransaction: %v", err)
+	}
+	if _, err := chain.MineBlock([]*Transaction{inflated, coinbasePaying(t, bob, minerReward)}); !errors.Is(err, ErrorTxInvalid) {
+		t.Fatalf("transaction paying more than it spends: got %v, want %v", err, ErrorTxInvalid)
+	}
+}
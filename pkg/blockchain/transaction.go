@@ -1,11 +1,12 @@
 package blockchain
 
 import (
-	"crypto/ecdsa"
+	"bytes"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"sort"
 	"strings"
 
 	"blockchain/pkg/crypto"
@@ -13,19 +14,74 @@ import (
 	"blockchain/pkg/wallet"
 )
 
-const minerReward = 20
+const (
+	minerReward = 20
+
+	// LockTimeThreshold separates lock times given as block heights (below)
+	// from lock times given as unix timestamps (at or above)
+	LockTimeThreshold = 500000000
+)
 
 type Transaction struct {
 	ID      []byte
 	Inputs  []TxInput
 	Outputs []TxOutput
+	// LockTime is the earliest block height or unix time the transaction
+	// can be included in a block, zero means no lock
+	LockTime int64
+}
+
+type TxOpt func(*Transaction)
+
+// WithLockTime sets the lock time of a transaction before it is signed
+func WithLockTime(lockTime int64) TxOpt {
+	return func(tx *Transaction) {
+		tx.LockTime = lockTime
+	}
+}
+
+// UTXOSource finds the outputs a new transaction spends, a *UTXOSet or a
+// node over RPC
+type UTXOSource interface {
+	FindSpendableUTXOs(pubKeyHash []byte, amount int) (int, map[string][]int, error)
+	FindTransaction(ID []byte) (*Transaction, error)
+}
+
+// FindPrevTransactions returns the transactions whose outputs tx spends by
+// id, to sign tx with
+func FindPrevTransactions(tx *Transaction, utxo UTXOSource) (map[string]*Transaction, error) {
+	prevTXs := make(map[string]*Transaction)
+	for _, in := range tx.Inputs {
+		if _, ok := prevTXs[hex.EncodeToString(in.ID)]; ok {
+			continue
+		}
+		prevTx, err := utxo.FindTransaction(in.ID)
+		if err != nil {
+			return nil, err
+		}
+		prevTXs[hex.EncodeToString(prevTx.ID)] = prevTx
+	}
+
+	return prevTXs, nil
 }
 
-func NewTransaction(from, to string, amount int, utxo *UTXOSet) (*Transaction, error) {
+func NewTransaction(from, to string, amount int, utxo UTXOSource, opts ...TxOpt) (*Transaction, error) {
+	return fundTransaction(from, NewTXOutput(amount, to), utxo, opts...)
+}
+
+// fundTransaction pays out from the UTXOs of the from wallet and sends the change back
+func fundTransaction(from string, out *TxOutput, utxo UTXOSource, opts ...TxOpt) (*Transaction, error) {
 	var (
 		inputs  []TxInput
 		outputs []TxOutput
 	)
+	amount := out.Value
+	// a transaction always spends at least one output, so zero value
+	// transactions still get a unique id and the sender pays for them
+	target := amount
+	if target == 0 {
+		target = 1
+	}
 
 	w, err := wallet.GetWallet(from)
 	if err != nil {
@@ -35,11 +91,11 @@ func NewTransaction(from, to string, amount int, utxo *UTXOSet) (*Transaction, e
 	pubKeyBytes := w.PublicKeyBytes()
 	pubKeyHash := crypto.HashPublicKey(pubKeyBytes)
 
-	acc, validOutputs, err := utxo.FindSpendableUTXOs(pubKeyHash, amount)
+	acc, validOutputs, err := utxo.FindSpendableUTXOs(pubKeyHash, target)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %s", ErrorTxCreateFailed, err)
 	}
-	if acc < amount {
+	if acc < target {
 		return nil, fmt.Errorf("%w: not enough funds", ErrorTxCreateFailed)
 	}
 
@@ -55,7 +111,6 @@ func NewTransaction(from, to string, amount int, utxo *UTXOSet) (*Transaction, e
 		}
 	}
 
-	out := NewTXOutput(amount, to)
 	outputs = append(outputs, *out)
 
 	if acc > amount {
@@ -69,11 +124,18 @@ func NewTransaction(from, to string, amount int, utxo *UTXOSet) (*Transaction, e
 		Inputs:  inputs,
 		Outputs: outputs,
 	}
+	for _, opt := range opts {
+		opt(tx)
+	}
 	if err := tx.SetID(); err != nil {
 		return nil, fmt.Errorf("%w: %s", ErrorTxCreateFailed, err)
 	}
 
-	if err := utxo.SignTransaction(tx, w.PrivateKey); err != nil {
+	prevTXs, err := FindPrevTransactions(tx, utxo)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrorTxCreateFailed, err)
+	}
+	if err := tx.Sign(w.PrivateKey, prevTXs); err != nil {
 		return nil, fmt.Errorf("%w: %s", ErrorTxCreateFailed, err)
 	}
 
@@ -89,7 +151,7 @@ func CoinbaseTx(to, data string) (*Transaction, error) {
 		data = fmt.Sprintf("%x", randData)
 	}
 
-	txIn := NewTxInput(nil, -1, nil)
+	txIn := NewTxInput(nil, -1, []byte(data))
 	txOut := NewTXOutput(minerReward, to)
 	tx := &Transaction{
 		Inputs:  []TxInput{*txIn},
@@ -102,48 +164,148 @@ func CoinbaseTx(to, data string) (*Transaction, error) {
 	return tx, nil
 }
 
-func (tx *Transaction) SetID() error {
-	tx.ID = nil
+// CoinbaseTxShares creates a coinbase splitting the miner reward between
+// addresses in proportion to their shares. What rounding leaves over goes to
+// the address with the most shares.
+func CoinbaseTxShares(data string, shares map[string]int) (*Transaction, error) {
+	var total int
+	addresses := make([]string, 0, len(shares))
+	for addr, n := range shares {
+		if n <= 0 {
+			continue
+		}
+		total += n
+		addresses = append(addresses, addr)
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("%w: no shares to pay out", ErrorTxCreateFailed)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		if shares[addresses[i]] != shares[addresses[j]] {
+			return shares[addresses[i]] > shares[addresses[j]]
+		}
+		return addresses[i] < addresses[j]
+	})
 
-	encode, err := tx.Serialize()
+	tx, err := CoinbaseTx(addresses[0], data)
 	if err != nil {
-		return fmt.Errorf("error while encoding transaction, %w", err)
+		return nil, err
+	}
+
+	paid := 0
+	for _, addr := range addresses[1:] {
+		value := minerReward * shares[addr] / total
+		if value == 0 {
+			continue
+		}
+		tx.Outputs = append(tx.Outputs, *NewTXOutput(value, addr))
+		paid += value
+	}
+	tx.Outputs[0].Value = minerReward - paid
+
+	if err := tx.SetID(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrorTxCreateFailed, err)
 	}
 
-	hash := sha256.Sum256(encode)
-	tx.ID = hash[:]
+	return tx, nil
+}
+
+func (tx *Transaction) SetID() error {
+	tx.ID = tx.Hash()
 
 	return nil
 }
 
+// Hash returns the id of the transaction, the hash of everything but the witnesses
+func (tx *Transaction) Hash() []byte {
+	hash := sha256.Sum256(tx.encode(false))
+
+	return hash[:]
+}
+
+// WitnessHash returns the hash of the transaction including its witnesses
+func (tx *Transaction) WitnessHash() []byte {
+	hash := sha256.Sum256(tx.encode(true))
+
+	return hash[:]
+}
+
 func (tx *Transaction) IsCoinbase() bool {
 	return len(tx.Inputs) == 1 && len(tx.Inputs[0].ID) == 0 && tx.Inputs[0].Out == -1
 }
 
-func (tx *Transaction) Sign(privKey *ecdsa.PrivateKey, prevTXs map[string]*Transaction) error {
+// IsFinal reports whether the transaction can be included in a block with
+// the given height, on top of blocks with the given median time past
+func (tx *Transaction) IsFinal(height int, medianTime int64) bool {
+	if tx.LockTime == 0 {
+		return true
+	}
+
+	if tx.LockTime < LockTimeThreshold {
+		return tx.LockTime <= int64(height)
+	}
+
+	return tx.LockTime <= medianTime
+}
+
+func (tx *Transaction) Sign(privKey crypto.Signer, prevTXs map[string]*Transaction) error {
+	return tx.SignWithHashType(privKey, prevTXs, SigHashAll)
+}
+
+// SignWithHashType signs every input owned by privKey, committing to the
+// parts of the transaction selected by hashType
+func (tx *Transaction) SignWithHashType(privKey crypto.Signer, prevTXs map[string]*Transaction, hashType SigHashType) error {
 	if tx.IsCoinbase() {
 		return nil
 	}
 
-	for _, in := range tx.Inputs {
-		if prevTXs[hex.EncodeToString(in.ID)].ID == nil {
-			return fmt.Errorf("%w: previous transaction is not correct", ErrorTxSignFailed)
-		}
+	if !hashType.IsValid() {
+		return fmt.Errorf("%w: invalid signature hash type %#x", ErrorTxSignFailed, byte(hashType))
 	}
 
-	txCopy := tx.TrimmedCopy()
-
-	for inID, in := range txCopy.Inputs {
+	for i, in := range tx.Inputs {
 		prevTX := prevTXs[hex.EncodeToString(in.ID)]
-		txCopy.Inputs[inID].PubKey = prevTX.Outputs[in.Out].PubKeyHash
-
-		if err := txCopy.SetID(); err != nil {
-			return fmt.Errorf("%w: %s", ErrorTxSignFailed, err)
+		if prevTX == nil || prevTX.ID == nil {
+			return fmt.Errorf("%w: previous transaction is not correct", ErrorTxSignFailed)
 		}
+		if in.Out < 0 || in.Out >= len(prevTX.Outputs) {
+			return fmt.Errorf("%w: input %d spends missing output %d of %x", ErrorTxSignFailed, i, in.Out, in.ID)
+		}
+	}
 
-		txCopy.Inputs[inID].PubKey = nil
-
-		tx.Inputs[inID].Signature = crypto.Sign(privKey, txCopy.ID)
+	pubKey := privKey.Public().Bytes()
+
+	for inID := range tx.Inputs {
+		in := &tx.Inputs[inID]
+		prevOut := prevTXs[hex.EncodeToString(in.ID)].Outputs[in.Out]
+
+		switch prevOut.Type {
+		case OutputMultisig:
+			slot, err := in.multisigSlot(pubKey)
+			if err != nil {
+				return fmt.Errorf("%w: %s", ErrorTxSignFailed, err)
+			}
+			if slot < 0 {
+				continue
+			}
+
+			signature, err := tx.signInput(inID, &prevOut, privKey, hashType)
+			if err != nil {
+				return fmt.Errorf("%w: %s", ErrorTxSignFailed, err)
+			}
+			in.Signatures[slot] = signature
+		default:
+			// inputs owned by other keys are left for their owners to sign
+			if !bytes.Equal(in.PubKey, pubKey) {
+				continue
+			}
+
+			signature, err := tx.signInput(inID, &prevOut, privKey, hashType)
+			if err != nil {
+				return fmt.Errorf("%w: %s", ErrorTxSignFailed, err)
+			}
+			in.Signature = signature
+		}
 	}
 
 	return nil
@@ -151,6 +313,17 @@ func (tx *Transaction) Sign(privKey *ecdsa.PrivateKey, prevTXs map[string]*Trans
 
 // Verify checks if the transaction is valid
 func (tx *Transaction) Verify(prevTXs map[string]*Transaction) bool {
+	return tx.verify(prevTXs, true)
+}
+
+// verify checks tx against the outputs it spends, the scripts and
+// signatures of its inputs only with checkScripts
+func (tx *Transaction) verify(prevTXs map[string]*Transaction, checkScripts bool) bool {
+	// the id commits to everything but the witnesses, which the signatures cover
+	if !bytes.Equal(tx.ID, tx.Hash()) || !tx.verifyOutputs() {
+		return false
+	}
+
 	// Coinbase type transactions don't have inputs
 	if tx.IsCoinbase() {
 		return true
@@ -158,24 +331,49 @@ func (tx *Transaction) Verify(prevTXs map[string]*Transaction) bool {
 
 	// Check if the transaction inputs are valid
 	for _, in := range tx.Inputs {
-		if prevTXs[hex.EncodeToString(in.ID)].ID == nil {
+		prevTX := prevTXs[hex.EncodeToString(in.ID)]
+		if prevTX == nil || prevTX.ID == nil || in.Out < 0 || in.Out >= len(prevTX.Outputs) {
+			return false
+		}
+		if in.hasUnusedData(prevTX.Outputs[in.Out].Type) {
 			return false
 		}
 	}
 
-	txCopy := tx.TrimmedCopy()
+	if !checkScripts {
+		return true
+	}
 
 	for inID, in := range tx.Inputs {
-		prevTXs := prevTXs[hex.EncodeToString(in.ID)]
-		txCopy.Inputs[inID].PubKey = prevTXs.Outputs[in.Out].PubKeyHash
-
-		if err := txCopy.SetID(); err != nil {
+		prevOut := prevTXs[hex.EncodeToString(in.ID)].Outputs[in.Out]
+
+		switch prevOut.Type {
+		case OutputMultisig:
+			if !tx.verifyMultisig(inID, &prevOut) {
+				return false
+			}
+		case OutputHTLC:
+			if !tx.verifyHTLC(inID, &prevOut) {
+				return false
+			}
+		case OutputData:
 			return false
+		default:
+			if !in.UsesKey(prevOut.PubKeyHash) || !tx.verifySignature(inID, &prevOut, in.PubKey, in.Signature) {
+				return false
+			}
 		}
+	}
 
-		txCopy.Inputs[inID].PubKey = nil
+	return true
+}
 
-		if !crypto.Verify(in.PubKey, txCopy.ID, in.Signature) {
+func (tx *Transaction) verifyOutputs() bool {
+	for _, out := range tx.Outputs {
+		if out.Value < 0 {
+			return false
+		}
+		if out.Type == OutputData && (out.Value != 0 || len(out.Data) == 0 || len(out.Data) > MaxDataSize) {
 			return false
 		}
 	}
@@ -200,13 +398,17 @@ func (tx *Transaction) TrimmedCopy() *Transaction {
 		outputs = append(outputs, TxOutput{
 			Value:      out.Value,
 			PubKeyHash: out.PubKeyHash,
+			Type:       out.Type,
+			HTLC:       out.HTLC,
+			Data:       out.Data,
 		})
 	}
 
 	return &Transaction{
-		ID:      tx.ID,
-		Inputs:  inputs,
-		Outputs: outputs,
+		ID:       tx.ID,
+		Inputs:   inputs,
+		Outputs:  outputs,
+		LockTime: tx.LockTime,
 	}
 }
 
@@ -214,6 +416,9 @@ func (tx *Transaction) String() string {
 	var lines []string
 
 	lines = append(lines, fmt.Sprintf("--- Transaction %x:", tx.ID))
+	if tx.LockTime != 0 {
+		lines = append(lines, fmt.Sprintf("LockTime: %d", tx.LockTime))
+	}
 
 	for i, in := range tx.Inputs {
 		lines = append(lines, fmt.Sprintf("Input %d:", i))
@@ -221,12 +426,34 @@ func (tx *Transaction) String() string {
 		lines = append(lines, fmt.Sprintf("  Out:     %d", in.Out))
 		lines = append(lines, fmt.Sprintf("  Signature: %x", in.Signature))
 		lines = append(lines, fmt.Sprintf("  PubKey: %x", in.PubKey))
+		if len(in.Preimage) > 0 {
+			lines = append(lines, fmt.Sprintf("  Preimage: %x", in.Preimage))
+		}
+		if len(in.Redeem) > 0 {
+			lines = append(lines, fmt.Sprintf("  Redeem: %x", in.Redeem))
+			for j, sig := range in.Signatures {
+				lines = append(lines, fmt.Sprintf("  Signature[%d]: %x", j, sig))
+			}
+		}
 	}
 
 	for i, out := range tx.Outputs {
 		lines = append(lines, fmt.Sprintf("Output %d:", i))
 		lines = append(lines, fmt.Sprintf("  Value:  %d", out.Value))
 		lines = append(lines, fmt.Sprintf("  PubKeyHash: %x", out.PubKeyHash))
+		switch out.Type {
+		case OutputMultisig:
+			lines = append(lines, "  Type:   multisig")
+		case OutputHTLC:
+			lines = append(lines, "  Type:   htlc")
+			lines = append(lines, fmt.Sprintf("  Recipient: %x", out.HTLC.Recipient))
+			lines = append(lines, fmt.Sprintf("  Sender: %x", out.HTLC.Sender))
+			lines = append(lines, fmt.Sprintf("  SecretHash: %x", out.HTLC.SecretHash))
+			lines = append(lines, fmt.Sprintf("  Timeout: %d", out.HTLC.Timeout))
+		case OutputData:
+			lines = append(lines, "  Type:   data")
+			lines = append(lines, fmt.Sprintf("  Data:   %x", out.Data))
+		}
 	}
 
 	return strings.Join(lines, "\n")
@@ -0,0 +1,219 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"blockchain/pkg/crypto"
+	"blockchain/pkg/wallet"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// signTestTx returns a transaction spending output 0 of a fixed previous
+// transaction paying key
+func signTestTx(t *testing.T, key crypto.Signer) (*Transaction, map[string]*Transaction) {
+	t.Helper()
+
+	pubKey := key.Public().Bytes()
+	prevTX := &Transaction{
+		ID:      bytes.Repeat([]byte{0x11}, 32),
+		Outputs: []TxOutput{{Value: 10, PubKeyHash: crypto.HashPublicKey(pubKey)}},
+	}
+
+	tx := &Transaction{
+		Inputs: []TxInput{{ID: prevTX.ID, Out: 0, PubKey: pubKey}},
+		Outputs: []TxOutput{
+			{Value: 7, PubKeyHash: bytes.Repeat([]byte{0x22}, 20)},
+			{Value: 3, PubKeyHash: crypto.HashPublicKey(pubKey)},
+		},
+		LockTime: 42,
+	}
+	if err := tx.SetID(); err != nil {
+		t.Fatalf("SetID: %v", err)
+	}
+
+	return tx, map[string]*Transaction{hex.EncodeToString(prevTX.ID): prevTX}
+}
+
+func TestTransactionSignVectors(t *testing.T) {
+	// signatures are deterministic, r||s or the Ed25519 signature followed by the hash type
+	secret := bytes.Repeat([]byte{0x01}, 32)
+
+	for _, tc := range []struct {
+		name      string
+		key       crypto.Signer
+		signature string
+	}{
+		{
+			name: "ecdsa secp256k1",
+			key:  &crypto.ECDSAPrivateKey{Key: secp256k1.PrivKeyFromBytes(secret).ToECDSA()},
+			signature: "b6f0eb90ffd24c84da07374fb1c2f5a425f8d2ac598c412efacd8474f5b72899" +
+				"7ff88375f7d0eef49175f9ea0f591f9a24e3923528d9d6987087e322ac7c2b82" + "01",
+		},
+		{
+			name: "ed25519",
+			key:  &crypto.Ed25519PrivateKey{Key: ed25519.NewKeyFromSeed(secret)},
+			signature: "6688b708cdaa75149accf2f66c7f83a690d0963e349fe44e0428f84cafb977bc" +
+				"c34db8e695734247596bd36ac8ba138565d4af88745ddf6edd0f42178a78600e" + "01",
+		},
+	} {
+		tx, prevTXs := signTestTx(t, tc.key)
+		if err := tx.Sign(tc.key, prevTXs); err != nil {
+			t.Fatalf("%s: Sign: %v", tc.name, err)
+		}
+
+		if got := hex.EncodeToString(tx.Inputs[0].Signature); got != tc.signature {
+			t.Errorf("%s: signature %s, want %s", tc.name, got, tc.signature)
+		}
+		if !tx.Verify(prevTXs) {
+			t.Errorf("%s: signed transaction does not verify", tc.name)
+		}
+	}
+}
+
+func TestLegacyWallet(t *testing.T) {
+	// a P-256 wallet addressed by the hash of its untagged key, as all
+	// wallets were before key types
+	w, err := wallet.NewWallet(crypto.KeyECDSA, crypto.WithCurve(crypto.CurveP256))
+	if err != nil {
+		t.Fatalf("NewWallet: %v", err)
+	}
+	w.PrivateKey.(*crypto.ECDSAPrivateKey).Legacy = true
+	w.PublicKey = w.PrivateKey.Public()
+	legacy := string(w.Address())
+
+	priv, err := crypto.EncodePrivateKey(w.PrivateKey)
+	if err != nil {
+		t.Fatalf("EncodePrivateKey: %v", err)
+	}
+	dir := filepath.Join("tmp", "wallets", legacy)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "private.pem"), priv, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := wallet.GetWallet(legacy)
+	if err != nil {
+		t.Fatalf("GetWallet: %v", err)
+	}
+	if got := string(loaded.Address()); got != legacy {
+		t.Fatalf("loaded wallet has address %s, want %s", got, legacy)
+	}
+
+	// coins paid to the legacy address can be spent
+	bob := newTestWallet(t)
+	chain := newTestChain(t, legacy)
+	tx, err := NewTransaction(legacy, bob, 5, NewUTXOSet(chain))
+	if err != nil {
+		t.Fatalf("NewTransaction: %v", err)
+	}
+	mineTestBlock(t, chain, bob, tx)
+
+	if got, want := balance(t, chain, legacy), minerReward-5; got != want {
+		t.Errorf("balance of the legacy wallet = %d, want %d", got, want)
+	}
+}
+
+func TestTransactionMalleability(t *testing.T) {
+	alice, bob, carol := newTestWallet(t), newTestWallet(t), newTestWallet(t)
+	chain := newTestChain(t, alice)
+	utxo := NewUTXOSet(chain)
+
+	var (
+		wallets []*wallet.Wallet
+		pubKeys [][]byte
+	)
+	for _, address := range []string{alice, bob, carol} {
+		w, err := wallet.GetWallet(address)
+		if err != nil {
+			t.Fatalf("GetWallet: %v", err)
+		}
+		wallets = append(wallets, w)
+		pubKeys = append(pubKeys, w.PublicKeyBytes())
+	}
+	script, err := wallet.NewMultisigScript(2, pubKeys)
+	if err != nil {
+		t.Fatalf("NewMultisigScript: %v", err)
+	}
+
+	fund, err := NewTransaction(alice, script.Address(), 10, utxo)
+	if err != nil {
+		t.Fatalf("NewTransaction: %v", err)
+	}
+	mineTestBlock(t, chain, alice, fund)
+
+	preimage := []byte("secret")
+	secretHash := sha256.Sum256(preimage)
+	lock, err := NewHTLCTransaction(alice, bob, 5, secretHash[:], 100, utxo)
+	if err != nil {
+		t.Fatalf("NewHTLCTransaction: %v", err)
+	}
+	mineTestBlock(t, chain, alice, lock)
+
+	// a spend of each output type, none of them mined
+	payment, err := NewTransaction(alice, bob, 5, utxo)
+	if err != nil {
+		t.Fatalf("NewTransaction: %v", err)
+	}
+	multisig, err := NewMultisigTransaction(script, carol, 4, utxo)
+	if err != nil {
+		t.Fatalf("NewMultisigTransaction: %v", err)
+	}
+	if err := chain.SignWithWallets(multisig, wallets[:2]...); err != nil {
+		t.Fatalf("SignWithWallets: %v", err)
+	}
+	claim, err := NewHTLCClaimTransaction(lock.ID, 0, preimage, utxo)
+	if err != nil {
+		t.Fatalf("NewHTLCClaimTransaction: %v", err)
+	}
+
+	// well formed values, so only the rules of the spent output reject them
+	fields := []struct {
+		name string
+		set  func(in *TxInput)
+	}{
+		{"pubkey", func(in *TxInput) { in.PubKey = pubKeys[2] }},
+		{"redeem", func(in *TxInput) { in.Redeem = script.Serialize() }},
+		{"preimage", func(in *TxInput) { in.Preimage = preimage }},
+	}
+
+	for _, tc := range []struct {
+		name string
+		tx   *Transaction
+	}{
+		{"pubkeyhash", payment},
+		{"multisig", multisig},
+		{"htlc claim", claim},
+	} {
+		if !chain.VerifyTransaction(tc.tx) {
+			t.Fatalf("%s: VerifyTransaction rejected the unmodified spend", tc.name)
+		}
+
+		for _, field := range fields {
+			data, err := tc.tx.Serialize()
+			if err != nil {
+				t.Fatalf("Serialize: %v", err)
+			}
+			relayed := &Transaction{}
+			if err := relayed.Deserialize(data); err != nil {
+				t.Fatalf("Deserialize: %v", err)
+			}
+
+			// a relay fills in the field and recomputes the id
+			field.set(&relayed.Inputs[0])
+			relayed.ID = relayed.Hash()
+
+			if !bytes.Equal(relayed.ID, tc.tx.ID) && chain.VerifyTransaction(relayed) {
+				t.Errorf("%s: setting the %s gave valid transaction %x, a second id of %x", tc.name, field.name, relayed.ID, tc.tx.ID)
+			}
+		}
+	}
+}
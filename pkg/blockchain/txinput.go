@@ -11,9 +11,22 @@ type TxInput struct {
 	// ID of the transaction that contains the output we're referencing
 	ID []byte
 	// Index of the output we're referencing
-	Out       int
+	Out    int
+	PubKey []byte
+	// Redeem is the serialized wallet.MultisigScript when spending a multisig output
+	Redeem []byte
+	// Preimage is the secret revealed when claiming a HTLC output
+	Preimage []byte
+
+	Witness
+}
+
+// Witness holds the signatures of an input. It is left out of the transaction id,
+// so re-encoding a signature can't change the id of a transaction or of its spenders.
+type Witness struct {
 	Signature []byte
-	PubKey    []byte
+	// Signatures holds one slot per key of the redeem script, unsigned slots are nil
+	Signatures [][]byte
 }
 
 func NewTxInput(id []byte, out int, pubKey []byte) *TxInput {
@@ -24,6 +37,20 @@ func NewTxInput(id []byte, out int, pubKey []byte) *TxInput {
 	}
 }
 
+// hasUnusedData reports whether in carries unlocking data the script of an
+// output of outType doesn't read. It is part of the transaction id without
+// being signed, so a relay could change the id by filling it in.
+func (in *TxInput) hasUnusedData(outType OutputType) bool {
+	switch outType {
+	case OutputMultisig:
+		return len(in.PubKey) > 0 || len(in.Preimage) > 0
+	case OutputHTLC:
+		return len(in.Redeem) > 0
+	default:
+		return len(in.Redeem) > 0 || len(in.Preimage) > 0
+	}
+}
+
 func (in *TxInput) UsesKey(pubKeyHash []byte) bool {
 	lockingHash := crypto.HashPublicKey(in.PubKey)
 
@@ -10,10 +10,47 @@ import (
 
 type TxOutputs []TxOutput
 
+// OutputType tells how an output is locked
+type OutputType int
+
+const (
+	// OutputPubKeyHash is locked to the hash of a single public key
+	OutputPubKeyHash OutputType = iota
+	// OutputMultisig is locked to the hash of a wallet.MultisigScript
+	OutputMultisig
+	// OutputHTLC is locked by the hash time-locked contract it carries
+	OutputHTLC
+	// OutputData carries arbitrary data and can never be spent
+	OutputData
+)
+
+func (t OutputType) String() string {
+	switch t {
+	case OutputPubKeyHash:
+		return "pubkeyhash"
+	case OutputMultisig:
+		return "multisig"
+	case OutputHTLC:
+		return "htlc"
+	case OutputData:
+		return "data"
+	default:
+		return "unknown"
+	}
+}
+
+// MaxDataSize is the maximum number of bytes an OutputData can carry
+const MaxDataSize = 80
+
 // TxOutput represents a transaction output
 type TxOutput struct {
 	Value      int
 	PubKeyHash []byte
+	Type       OutputType
+	// HTLC is the contract of an OutputHTLC, PubKeyHash holds its hash
+	HTLC *HTLC
+	// Data is the payload of an OutputData
+	Data []byte
 }
 
 func NewTXOutput(value int, address string) *TxOutput {
@@ -26,18 +63,39 @@ func NewTXOutput(value int, address string) *TxOutput {
 	return out
 }
 
+// NewDataOutput creates an unspendable, zero value output carrying data
+func NewDataOutput(data []byte) (*TxOutput, error) {
+	if len(data) == 0 || len(data) > MaxDataSize {
+		return nil, fmt.Errorf("data output must carry 1 to %d bytes, got %d", MaxDataSize, len(data))
+	}
+
+	return &TxOutput{
+		Type: OutputData,
+		Data: data,
+	}, nil
+}
+
 func (out *TxOutput) Lock(address string) {
 	pubKeyHash, err := wallet.PubKeyHashFromAddress(address)
 	if err != nil {
 		panic(fmt.Sprintf("failed to get public key hash from address: %s", err))
 	}
 	out.PubKeyHash = pubKeyHash
+
+	if wallet.IsMultisigAddress(address) {
+		out.Type = OutputMultisig
+	}
 }
 
 func (out *TxOutput) IsLockedWithKey(pubKeyHash []byte) bool {
 	return bytes.Equal(out.PubKeyHash, pubKeyHash)
 }
 
+// IsSpendable reports whether the output can be spent and so belongs in the UTXO set
+func (out *TxOutput) IsSpendable() bool {
+	return out.Type != OutputData
+}
+
 func (outs *TxOutputs) Serialize() ([]byte, error) {
 	return util.GobEncode(outs)
 }
@@ -3,21 +3,45 @@ package blockchain
 import (
 	"bytes"
 	"encoding/hex"
+	"errors"
+	"fmt"
 
 	"github.com/dgraph-io/badger"
+
+	"blockchain/pkg/util"
 )
 
 var (
 	utxoPrefix = []byte("utxo-")
 	prefixLen  = len(utxoPrefix)
+	// utxoVersionKey holds the format of the UTXO set values, it is not
+	// under utxoPrefix so reindexing keeps it
+	utxoVersionKey = []byte("utxoset-version")
 )
 
-const collectSize = 100000
+const (
+	collectSize = 100000
+	// utxoVersion is bumped whenever the format of the UTXO set values
+	// changes, sets of another version are rebuilt on open. Version 1 stores
+	// the UnspentOutputs of each transaction by output index.
+	utxoVersion = 1
+)
 
 type UTXOSet struct {
 	*BlockChain
 }
 
+// UnspentOutputs holds the unspent outputs of one transaction by output index
+type UnspentOutputs map[int]TxOutput
+
+func (outs UnspentOutputs) Serialize() ([]byte, error) {
+	return util.GobEncode(outs)
+}
+
+func (outs *UnspentOutputs) Deserialize(data []byte) error {
+	return util.GobDecode(data, outs)
+}
+
 func NewUTXOSet(chain *BlockChain) *UTXOSet {
 	return &UTXOSet{chain}
 }
@@ -27,7 +51,10 @@ func (u *UTXOSet) Reindex() error {
 		return err
 	}
 
-	UTXOs := u.BlockChain.FindUTXOs()
+	UTXOs, err := u.BlockChain.FindUTXOs()
+	if err != nil {
+		return err
+	}
 
 	return u.database.Update(func(txn *badger.Txn) error {
 		for txID, outs := range UTXOs {
@@ -42,72 +69,146 @@ func (u *UTXOSet) Reindex() error {
 				return err
 			}
 
-			return txn.Set(key, val)
+			if err := txn.Set(key, val); err != nil {
+				return err
+			}
 		}
-		return nil
+
+		return txn.Set(utxoVersionKey, []byte{utxoVersion})
 	})
 }
 
-func (u *UTXOSet) Update(block *Block) error {
-	return u.database.Update(func(txn *badger.Txn) error {
-		for _, tx := range block.Transactions {
-			if !tx.IsCoinbase() {
-				for _, in := range tx.Inputs {
-					updatedOuts := TxOutputs{}
-
-					inID := append(utxoPrefix, in.ID...)
-					item, err := txn.Get(inID)
-					if err != nil {
-						return err
-					}
+// syncUTXOSet rebuilds the UTXO set if it was written in another format,
+// by an older release for instance
+func (bc *BlockChain) syncUTXOSet() error {
+	var version []byte
+	err := bc.database.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(utxoVersionKey)
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
 
-					var outs TxOutputs
-					err = item.Value(func(val []byte) error {
-						return outs.Deserialize(val)
-					})
-					if err != nil {
-						return err
-					}
+		version, err = item.ValueCopy(nil)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(version, []byte{utxoVersion}) {
+		return nil
+	}
 
-					for outIdx, out := range outs {
-						if outIdx != in.Out {
-							updatedOuts = append(updatedOuts, out)
-						}
-					}
+	return NewUTXOSet(bc).Reindex()
+}
 
-					if len(updatedOuts) == 0 {
-						if err = txn.Delete(inID); err != nil {
-							return err
-						}
-						continue
-					}
+// updateUTXOs moves the UTXO set past the transactions of block in txn.
+// It fails with ErrorTxDoubleSpend if an input spends an output which is not
+// unspent, because a transaction of the chain or of the block spent it first.
+func updateUTXOs(txn *badger.Txn, block *Block) error {
+	for _, tx := range block.Transactions {
+		if !tx.IsCoinbase() {
+			for _, in := range tx.Inputs {
+				inID := append(utxoPrefix, in.ID...)
+				updatedOuts, err := unspentOutputs(txn, in.ID)
+				if err != nil {
+					return err
+				}
+				if _, ok := updatedOuts[in.Out]; !ok {
+					return fmt.Errorf("%w: output %x-%d, transaction: %x", ErrorTxDoubleSpend, in.ID, in.Out, tx.ID)
+				}
 
-					encoded, err := updatedOuts.Serialize()
-					if err != nil {
-						return err
-					}
-					if err = txn.Set(inID, encoded); err != nil {
+				delete(updatedOuts, in.Out)
+
+				if len(updatedOuts) == 0 {
+					if err = txn.Delete(inID); err != nil {
 						return err
 					}
+					continue
+				}
+
+				encoded, err := updatedOuts.Serialize()
+				if err != nil {
+					return err
+				}
+				if err = txn.Set(inID, encoded); err != nil {
+					return err
 				}
 			}
+		}
+
+		newOutputs := UnspentOutputs{}
+		for outIdx, out := range tx.Outputs {
+			if out.IsSpendable() {
+				newOutputs[outIdx] = out
+			}
+		}
+		if len(newOutputs) == 0 {
+			continue
+		}
+
+		txID := append(utxoPrefix, tx.ID...)
+		encoded, err := newOutputs.Serialize()
+		if err != nil {
+			return err
+		}
+		if err = txn.Set(txID, encoded); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
 
-			newOutputs := TxOutputs{}
-			for _, out := range tx.Outputs {
-				newOutputs = append(newOutputs, out)
+// unspentOutputs returns the unspent outputs of the transaction with the
+// given id, none if it has no unspent output left
+func unspentOutputs(txn *badger.Txn, txID []byte) (UnspentOutputs, error) {
+	item, err := txn.Get(append(utxoPrefix, txID...))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return UnspentOutputs{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var outs UnspentOutputs
+	if err := item.Value(func(val []byte) error {
+		return outs.Deserialize(val)
+	}); err != nil {
+		return nil, err
+	}
+
+	return outs, nil
+}
+
+// spendsUnspent reports whether every input of tx spends an unspent
+// output, each of them once
+func (bc *BlockChain) spendsUnspent(tx *Transaction) bool {
+	spent := make(map[string]struct{}, len(tx.Inputs))
+
+	err := bc.database.View(func(txn *badger.Txn) error {
+		for _, in := range tx.Inputs {
+			key := fmt.Sprintf("%x-%d", in.ID, in.Out)
+			if _, ok := spent[key]; ok {
+				return ErrorTxDoubleSpend
 			}
+			spent[key] = struct{}{}
 
-			txID := append(utxoPrefix, tx.ID...)
-			encoded, err := newOutputs.Serialize()
+			outs, err := unspentOutputs(txn, in.ID)
 			if err != nil {
 				return err
 			}
-			if err = txn.Set(txID, encoded); err != nil {
-				return err
+			if _, ok := outs[in.Out]; !ok {
+				return ErrorTxDoubleSpend
 			}
 		}
+
 		return nil
 	})
+
+	return err == nil
 }
 
 func (u *UTXOSet) DeleteByPrefix(prefix []byte) error {
@@ -180,7 +281,7 @@ func (u *UTXOSet) FindUTXOs(pubKeyHash []byte) (*TxOutputs, error) {
 
 		for it.Seek(utxoPrefix); it.ValidForPrefix(utxoPrefix); it.Next() {
 			item := it.Item()
-			var outs TxOutputs
+			var outs UnspentOutputs
 			err := item.Value(func(val []byte) error {
 				return outs.Deserialize(val)
 			})
@@ -216,7 +317,7 @@ func (u *UTXOSet) FindSpendableUTXOs(pubKeyHash []byte, amount int) (int, map[st
 
 		for it.Seek(utxoPrefix); it.ValidForPrefix(utxoPrefix); it.Next() {
 			item := it.Item()
-			var outs TxOutputs
+			var outs UnspentOutputs
 			err := item.Value(func(val []byte) error {
 				return outs.Deserialize(val)
 			})
@@ -0,0 +1,72 @@
+package blockchain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDoubleSpend(t *testing.T) {
+	alice, bob, carol := newTestWallet(t), newTestWallet(t), newTestWallet(t)
+	chain := newTestChain(t, alice)
+	utxo := NewUTXOSet(chain)
+
+	// both spend the genesis output of alice
+	toBob, err := NewTransaction(alice, bob, 5, utxo)
+	if err != nil {
+		t.Fatalf("NewTransaction: %v", err)
+	}
+	toCarol, err := NewTransaction(alice, carol, 5, utxo)
+	if err != nil {
+		t.Fatalf("NewTransaction: %v", err)
+	}
+
+	cbTx, err := CoinbaseTx(alice, "")
+	if err != nil {
+		t.Fatalf("CoinbaseTx: %v", err)
+	}
+	if _, err := chain.MineBlock([]*Transaction{toBob, toCarol, cbTx}); !errors.Is(err, ErrorTxDoubleSpend) {
+		t.Fatalf("both in one block: got %v, want %v", err, ErrorTxDoubleSpend)
+	}
+
+	// a transaction spending an output twice is rejected on its own
+	twice := *toBob
+	twice.Inputs = append(append([]TxInput{}, toBob.Inputs...), toBob.Inputs...)
+	if chain.VerifyTransaction(&twice) {
+		t.Fatal("VerifyTransaction accepted a transaction spending an output twice")
+	}
+
+	mineTestBlock(t, chain, alice, toBob)
+	if chain.VerifyTransaction(toCarol) {
+		t.Fatal("VerifyTransaction accepted a transaction spending a spent output")
+	}
+	if _, err := chain.MineBlock([]*Transaction{toCarol, cbTx}); !errors.Is(err, ErrorTxInvalid) {
+		t.Fatalf("spent in an earlier block: got %v, want %v", err, ErrorTxInvalid)
+	}
+
+	// the rejected blocks left the UTXO set alone
+	if got, want := balance(t, chain, alice), 2*minerReward-5; got != want {
+		t.Errorf("balance of alice = %d, want %d", got, want)
+	}
+	if got := balance(t, chain, bob); got != 5 {
+		t.Errorf("balance of bob = %d, want 5", got)
+	}
+	if got := balance(t, chain, carol); got != 0 {
+		t.Errorf("balance of carol = %d, want 0", got)
+	}
+
+	// the set built incrementally matches a rebuild from the blocks
+	count, err := utxo.CountTransactions()
+	if err != nil {
+		t.Fatalf("CountTransactions: %v", err)
+	}
+	if err := utxo.Reindex(); err != nil {
+		t.Fatalf("Reindex: %v", err)
+	}
+	reindexed, err := utxo.CountTransactions()
+	if err != nil {
+		t.Fatalf("CountTransactions: %v", err)
+	}
+	if count != reindexed {
+		t.Errorf("UTXO set holds %d transactions, %d after reindexing", count, reindexed)
+	}
+}
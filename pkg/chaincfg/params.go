@@ -0,0 +1,151 @@
+package chaincfg
+
+import (
+	"fmt"
+
+	"blockchain/pkg/consensus"
+	"blockchain/pkg/consensus/poa"
+	"blockchain/pkg/consensus/pow"
+	"blockchain/pkg/crypto"
+	"blockchain/pkg/wallet"
+)
+
+// Checkpoint is a block every chain of a network contains
+type Checkpoint struct {
+	Height int
+	Hash   []byte
+}
+
+// Params are the rules a network is created with. They are stored with the
+// chain, every node of a network must use the same parameters.
+type Params struct {
+	Name string
+
+	// Curve is the curve ECDSA wallets of the network are created on
+	Curve crypto.Curve
+
+	// NewEngine creates the consensus engine sealing and verifying blocks
+	NewEngine func() consensus.Engine
+
+	// MaxBlockWeight bounds the total weight of the transactions of a block,
+	// bytes outside of witnesses count four times
+	MaxBlockWeight int
+	// MaxBlockSigOps bounds the signature checks the transactions of a block take
+	MaxBlockSigOps int
+
+	// Checkpoints are blocks chains of the network must contain, forks
+	// replacing them are rejected
+	Checkpoints []Checkpoint
+	// AssumeValid is a block whose ancestors are known to have valid scripts
+	// and signatures, they are not checked again when syncing. It is a
+	// checkpoint too, so a chain skipping the checks must lead to it.
+	AssumeValid *Checkpoint
+}
+
+const (
+	maxBlockWeight = 4000000
+	maxBlockSigOps = 80000
+
+	powDifficulty = 12
+	// memory hard hashes are much slower, their networks need less work per block
+	memoryHardDifficulty = 8
+)
+
+var (
+	// MainNetParams use secp256k1 keys, compatible with existing wallet tooling
+	MainNetParams = Params{
+		Name:  "mainnet",
+		Curve: crypto.CurveSecp256k1,
+		NewEngine: func() consensus.Engine {
+			return pow.New(powDifficulty)
+		},
+		MaxBlockWeight: maxBlockWeight,
+		MaxBlockSigOps: maxBlockSigOps,
+	}
+
+	// DevNetParams keep the P-256 keys local chains were always created with
+	DevNetParams = Params{
+		Name:  "devnet",
+		Curve: crypto.CurveP256,
+		NewEngine: func() consensus.Engine {
+			return pow.New(powDifficulty)
+		},
+		MaxBlockWeight: maxBlockWeight,
+		MaxBlockSigOps: maxBlockSigOps,
+	}
+
+	// ScryptNetParams mine with scrypt, which takes away much of the edge of SHA-256 hardware
+	ScryptNetParams = Params{
+		Name:  "scryptnet",
+		Curve: crypto.CurveP256,
+		NewEngine: func() consensus.Engine {
+			return pow.New(memoryHardDifficulty, pow.WithAlgorithm(pow.Scrypt))
+		},
+		MaxBlockWeight: maxBlockWeight,
+		MaxBlockSigOps: maxBlockSigOps,
+	}
+
+	// Argon2NetParams mine with Argon2id, its memory cost keeps mining on general purpose hardware
+	Argon2NetParams = Params{
+		Name:  "argon2net",
+		Curve: crypto.CurveP256,
+		NewEngine: func() consensus.Engine {
+			return pow.New(memoryHardDifficulty, pow.WithAlgorithm(pow.Argon2id))
+		},
+		MaxBlockWeight: maxBlockWeight,
+		MaxBlockSigOps: maxBlockSigOps,
+	}
+
+	// AuthorityNetParams are for permissioned networks, where the signers
+	// listed in the genesis block take turns sealing blocks
+	AuthorityNetParams = Params{
+		Name:  "authority",
+		Curve: crypto.CurveP256,
+		NewEngine: func() consensus.Engine {
+			return poa.New(wallet.FindSigner)
+		},
+		MaxBlockWeight: maxBlockWeight,
+		MaxBlockSigOps: maxBlockSigOps,
+	}
+
+	// DefaultParams are used when no network is given, and for chains
+	// created before parameters were stored
+	DefaultParams = &DevNetParams
+)
+
+var networks = map[string]*Params{
+	MainNetParams.Name:      &MainNetParams,
+	DevNetParams.Name:       &DevNetParams,
+	ScryptNetParams.Name:    &ScryptNetParams,
+	Argon2NetParams.Name:    &Argon2NetParams,
+	AuthorityNetParams.Name: &AuthorityNetParams,
+}
+
+// ParamsByName returns the parameters of the named network
+func ParamsByName(name string) (*Params, error) {
+	params, ok := networks[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown network: %s", name)
+	}
+
+	return params, nil
+}
+
+// Checkpoint returns the hash the block at height must have, if the network fixes it
+func (p *Params) Checkpoint(height int) ([]byte, bool) {
+	for _, cp := range p.Checkpoints {
+		if cp.Height == height {
+			return cp.Hash, true
+		}
+	}
+	if p.AssumeValid != nil && p.AssumeValid.Height == height {
+		return p.AssumeValid.Hash, true
+	}
+
+	return nil, false
+}
+
+// KeyOpts returns the options wallet keys of the network are generated with
+func (p *Params) KeyOpts() []crypto.KeyOpt {
+	return []crypto.KeyOpt{crypto.WithCurve(p.Curve)}
+}
@@ -0,0 +1,35 @@
+package consensus
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrorSealInvalid       = errors.New("block seal is invalid")
+	ErrorDifficultyInvalid = errors.New("block difficulty is invalid")
+	ErrorUnknownParent     = errors.New("parent block is unknown")
+	// ErrorNotInTurn is returned by Prepare when this node may not seal the
+	// next block, miners wait for another block and try again
+	ErrorNotInTurn = errors.New("not the turn of this node to seal a block")
+)
+
+// ChainReader gives engines access to the headers already in the chain
+type ChainReader interface {
+	GetHeader(hash []byte) (*Header, error)
+}
+
+// Engine decides how blocks are sealed and which seals are valid.
+// BlockChain only talks to the engine of its network through this interface.
+type Engine interface {
+	// Prepare sets the consensus fields of a new header, like its difficulty
+	Prepare(chain ChainReader, header *Header) error
+	// Seal completes a prepared header, setting its Nonce, Extra and Hash as needed.
+	// It gives up with ctx.Err() when ctx is done.
+	Seal(ctx context.Context, header *Header) error
+	// VerifySeal checks the consensus fields and the seal of a header
+	VerifySeal(chain ChainReader, header *Header) error
+	// CalcDifficulty returns the difficulty of the block following parent,
+	// parent is nil for the genesis block
+	CalcDifficulty(chain ChainReader, parent *Header) int
+}
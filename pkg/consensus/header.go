@@ -0,0 +1,51 @@
+package consensus
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// Header holds the fields of a block the consensus engine seals
+type Header struct {
+	Timestamp int64
+	Hash      []byte
+	PrevHash  []byte
+	// TxRoot is the merkle root of the transaction ids
+	TxRoot []byte
+	// WitnessRoot is the merkle root of the witness hashes of the transactions
+	WitnessRoot []byte
+	Height      int
+	Difficulty  int
+	Nonce       int
+	// Extra carries engine specific data
+	Extra []byte
+}
+
+// SealData returns the encoding of every field but Hash and Extra, the
+// data a seal commits to
+func (h *Header) SealData() []byte {
+	var data []byte
+
+	data = binary.AppendVarint(data, h.Timestamp)
+	data = appendBytes(data, h.PrevHash)
+	data = appendBytes(data, h.TxRoot)
+	data = appendBytes(data, h.WitnessRoot)
+	data = binary.AppendVarint(data, int64(h.Height))
+	data = binary.AppendVarint(data, int64(h.Difficulty))
+	data = binary.AppendVarint(data, int64(h.Nonce))
+
+	return data
+}
+
+// SealHash returns the hash of SealData
+func (h *Header) SealHash() []byte {
+	hash := sha256.Sum256(h.SealData())
+
+	return hash[:]
+}
+
+func appendBytes(data, b []byte) []byte {
+	data = binary.AppendUvarint(data, uint64(len(b)))
+
+	return append(data, b...)
+}
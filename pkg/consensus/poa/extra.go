@@ -0,0 +1,124 @@
+package poa
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// VoteKind is the change to the signer set a sealer proposes with its block
+type VoteKind byte
+
+const (
+	VoteNone VoteKind = iota
+	VoteAdd
+	VoteRemove
+)
+
+// seal is the Extra field of a block sealed by a signer
+type seal struct {
+	Vote   VoteKind
+	Target []byte // public key hash the vote is about
+	PubKey []byte
+	Sig    []byte
+}
+
+// encode writes the seal, withSig false gives the data the signature commits to
+func (s *seal) encode(withSig bool) []byte {
+	data := []byte{byte(s.Vote)}
+	data = appendBytes(data, s.Target)
+	data = appendBytes(data, s.PubKey)
+	if withSig {
+		data = appendBytes(data, s.Sig)
+	}
+
+	return data
+}
+
+func decodeSeal(data []byte) (*seal, error) {
+	if len(data) == 0 {
+		return nil, errors.New("block is not sealed")
+	}
+
+	s := &seal{Vote: VoteKind(data[0])}
+	if s.Vote > VoteRemove {
+		return nil, errors.New("unknown vote kind")
+	}
+
+	var err error
+	r := data[1:]
+	if s.Target, r, err = readBytes(r); err != nil {
+		return nil, err
+	}
+	if s.PubKey, r, err = readBytes(r); err != nil {
+		return nil, err
+	}
+	if s.Sig, r, err = readBytes(r); err != nil {
+		return nil, err
+	}
+	if len(r) != 0 {
+		return nil, errors.New("seal has trailing data")
+	}
+	if (s.Vote == VoteNone) != (len(s.Target) == 0) {
+		return nil, errors.New("vote target does not match vote kind")
+	}
+
+	return s, nil
+}
+
+// GenesisExtra encodes the initial signers, given by their public key
+// hashes, into the Extra field of the genesis block
+func GenesisExtra(signers [][]byte) []byte {
+	data := binary.AppendUvarint(nil, uint64(len(signers)))
+	for _, signer := range signers {
+		data = appendBytes(data, signer)
+	}
+
+	return data
+}
+
+func decodeGenesisExtra(data []byte) ([][]byte, error) {
+	if len(data) == 0 {
+		return nil, errors.New("genesis block lists no signers")
+	}
+
+	count, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, errors.New("invalid signer list")
+	}
+	if count == 0 {
+		return nil, errors.New("genesis block has no signers")
+	}
+
+	var (
+		signers [][]byte
+		signer  []byte
+		err     error
+	)
+	r := data[n:]
+	for i := uint64(0); i < count; i++ {
+		if signer, r, err = readBytes(r); err != nil {
+			return nil, err
+		}
+		signers = append(signers, signer)
+	}
+	if len(r) != 0 {
+		return nil, errors.New("signer list has trailing data")
+	}
+
+	return signers, nil
+}
+
+func appendBytes(data, b []byte) []byte {
+	data = binary.AppendUvarint(data, uint64(len(b)))
+
+	return append(data, b...)
+}
+
+func readBytes(data []byte) ([]byte, []byte, error) {
+	size, n := binary.Uvarint(data)
+	if n <= 0 || uint64(len(data)-n) < size {
+		return nil, nil, errors.New("extra data is truncated")
+	}
+
+	return data[n : n+int(size)], data[n+int(size):], nil
+}
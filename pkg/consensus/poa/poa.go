@@ -0,0 +1,249 @@
+package poa
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+
+	"blockchain/pkg/consensus"
+	"blockchain/pkg/crypto"
+)
+
+var _ consensus.Engine = (*ProofOfAuthority)(nil)
+
+var (
+	ErrorUnauthorized = errors.New("block is not sealed by an authorized signer")
+	ErrorOutOfTurn    = errors.New("block is sealed out of turn")
+	ErrorVoteInvalid  = errors.New("block carries an invalid vote")
+)
+
+// Keyring returns the private key of a signer held by this node
+type Keyring func(pubKeyHash []byte) (crypto.Signer, error)
+
+// ProofOfAuthority lets a set of signers take turns sealing blocks. The initial
+// signers are listed in the genesis block, signers vote in the blocks they
+// seal to add or remove signers, a change needs more than half of the signers.
+type ProofOfAuthority struct {
+	keyring Keyring
+
+	mu        sync.Mutex
+	proposals map[string]VoteKind
+	snapshots map[string]*snapshot
+}
+
+func New(keyring Keyring) *ProofOfAuthority {
+	return &ProofOfAuthority{
+		keyring:   keyring,
+		proposals: make(map[string]VoteKind),
+		snapshots: make(map[string]*snapshot),
+	}
+}
+
+// Propose makes the blocks sealed by this node vote on adding or removing
+// the signer with the given public key hash, until the vote passes
+func (poa *ProofOfAuthority) Propose(pubKeyHash []byte, vote VoteKind) {
+	poa.mu.Lock()
+	defer poa.mu.Unlock()
+
+	if vote == VoteNone {
+		delete(poa.proposals, string(pubKeyHash))
+		return
+	}
+	poa.proposals[string(pubKeyHash)] = vote
+}
+
+// Signers returns the public key hashes allowed to seal the block after parent
+func (poa *ProofOfAuthority) Signers(chain consensus.ChainReader, parent []byte) ([][]byte, error) {
+	snap, err := poa.snapshot(chain, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([][]byte{}, snap.signers...), nil
+}
+
+func (poa *ProofOfAuthority) Prepare(chain consensus.ChainReader, header *consensus.Header) error {
+	header.Difficulty = poa.CalcDifficulty(chain, nil)
+	header.Nonce = 0
+
+	// the genesis block lists the signers instead of being sealed by one
+	if header.Height == 0 {
+		_, err := decodeGenesisExtra(header.Extra)
+		return err
+	}
+
+	snap, err := poa.snapshot(chain, header.PrevHash)
+	if err != nil {
+		return err
+	}
+
+	// nodes without the key of the in-turn signer wait for the next block
+	signer := snap.inTurn(header.Height)
+	key, err := poa.keyring(signer)
+	if err != nil {
+		return fmt.Errorf("%w: no key for in-turn signer %x: %s", consensus.ErrorNotInTurn, signer, err)
+	}
+
+	s := &seal{PubKey: key.Public().Bytes()}
+
+	poa.mu.Lock()
+	for target, vote := range poa.proposals {
+		if snap.validVote(vote, []byte(target)) {
+			s.Vote, s.Target = vote, []byte(target)
+			break
+		}
+		// the proposal passed already
+		delete(poa.proposals, target)
+	}
+	poa.mu.Unlock()
+
+	header.Extra = s.encode(false)
+
+	return nil
+}
+
+func (poa *ProofOfAuthority) Seal(ctx context.Context, header *consensus.Header) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if header.Height > 0 {
+		s, err := decodeUnsignedSeal(header.Extra)
+		if err != nil {
+			return err
+		}
+
+		key, err := poa.keyring(crypto.HashPublicKey(s.PubKey))
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrorUnauthorized, err)
+		}
+
+		s.Sig, err = key.Sign(sealHash(header, s))
+		if err != nil {
+			return err
+		}
+		header.Extra = s.encode(true)
+	}
+
+	header.Hash = blockHash(header)
+
+	return nil
+}
+
+func (poa *ProofOfAuthority) VerifySeal(chain consensus.ChainReader, header *consensus.Header) error {
+	if header.Difficulty != poa.CalcDifficulty(chain, nil) || header.Nonce != 0 {
+		return consensus.ErrorDifficultyInvalid
+	}
+	if !bytes.Equal(header.Hash, blockHash(header)) {
+		return consensus.ErrorSealInvalid
+	}
+
+	if header.Height == 0 {
+		_, err := decodeGenesisExtra(header.Extra)
+		return err
+	}
+
+	snap, err := poa.snapshot(chain, header.PrevHash)
+	if err != nil {
+		return err
+	}
+
+	s, err := decodeSeal(header.Extra)
+	if err != nil {
+		return fmt.Errorf("%w: %s", consensus.ErrorSealInvalid, err)
+	}
+
+	signer := crypto.HashPublicKey(s.PubKey)
+	if !snap.isSigner(signer) {
+		return fmt.Errorf("%w: %x", ErrorUnauthorized, signer)
+	}
+	if !bytes.Equal(signer, snap.inTurn(header.Height)) {
+		return fmt.Errorf("%w: %x at height %d", ErrorOutOfTurn, signer, header.Height)
+	}
+	if !snap.validVote(s.Vote, s.Target) {
+		return ErrorVoteInvalid
+	}
+	if !crypto.Verify(s.PubKey, sealHash(header, s), s.Sig) {
+		return consensus.ErrorSealInvalid
+	}
+
+	return nil
+}
+
+// CalcDifficulty is constant, blocks are weighed equally
+func (poa *ProofOfAuthority) CalcDifficulty(_ consensus.ChainReader, _ *consensus.Header) int {
+	return 1
+}
+
+// snapshot returns the signer set after the block with the given hash
+func (poa *ProofOfAuthority) snapshot(chain consensus.ChainReader, hash []byte) (*snapshot, error) {
+	var headers []*consensus.Header
+
+	poa.mu.Lock()
+	snap := poa.snapshots[string(hash)]
+	poa.mu.Unlock()
+
+	// walk back to the newest known snapshot, or the genesis block
+	for snap == nil {
+		header, err := chain.GetHeader(hash)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", consensus.ErrorUnknownParent, err)
+		}
+
+		if header.Height == 0 {
+			signers, err := decodeGenesisExtra(header.Extra)
+			if err != nil {
+				return nil, err
+			}
+			snap = newSnapshot(signers)
+			break
+		}
+
+		headers = append(headers, header)
+		hash = header.PrevHash
+
+		poa.mu.Lock()
+		snap = poa.snapshots[string(hash)]
+		poa.mu.Unlock()
+	}
+
+	for i := len(headers) - 1; i >= 0; i-- {
+		s, err := decodeSeal(headers[i].Extra)
+		if err != nil {
+			return nil, err
+		}
+
+		snap = snap.copy()
+		if err := snap.apply(crypto.HashPublicKey(s.PubKey), s.Vote, s.Target); err != nil {
+			return nil, err
+		}
+
+		poa.mu.Lock()
+		poa.snapshots[string(headers[i].Hash)] = snap
+		poa.mu.Unlock()
+	}
+
+	return snap, nil
+}
+
+// sealHash is what signers sign, the header and their vote
+func sealHash(header *consensus.Header, s *seal) []byte {
+	hash := sha256.Sum256(append(header.SealData(), s.encode(false)...))
+
+	return hash[:]
+}
+
+// blockHash commits to the whole header including the seal
+func blockHash(header *consensus.Header) []byte {
+	hash := sha256.Sum256(append(header.SealData(), header.Extra...))
+
+	return hash[:]
+}
+
+func decodeUnsignedSeal(data []byte) (*seal, error) {
+	// an unsigned seal is a seal with an empty signature
+	return decodeSeal(append(append([]byte{}, data...), 0))
+}
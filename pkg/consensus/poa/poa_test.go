@@ -0,0 +1,240 @@
+package poa
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"blockchain/pkg/consensus"
+	"blockchain/pkg/crypto"
+)
+
+// testChain is a consensus.ChainReader of the headers sealed so far
+type testChain struct {
+	headers map[string]*consensus.Header
+	tip     *consensus.Header
+}
+
+func (c *testChain) GetHeader(hash []byte) (*consensus.Header, error) {
+	header, ok := c.headers[string(hash)]
+	if !ok {
+		return nil, fmt.Errorf("no header %x", hash)
+	}
+
+	return header, nil
+}
+
+func (c *testChain) add(header *consensus.Header) {
+	c.headers[string(header.Hash)] = header
+	c.tip = header
+}
+
+type testSigner struct {
+	key  crypto.Signer
+	hash []byte
+}
+
+func newTestSigners(t *testing.T, n int) []*testSigner {
+	t.Helper()
+
+	signers := make([]*testSigner, 0, n)
+	for i := 0; i < n; i++ {
+		key, err := crypto.GenerateKey(crypto.KeyECDSA)
+		if err != nil {
+			t.Fatalf("GenerateKey: %v", err)
+		}
+		signers = append(signers, &testSigner{key: key, hash: crypto.HashPublicKey(key.Public().Bytes())})
+	}
+
+	return signers
+}
+
+// keyring holds the keys of signers
+func keyring(signers ...*testSigner) Keyring {
+	return func(pubKeyHash []byte) (crypto.Signer, error) {
+		for _, s := range signers {
+			if bytes.Equal(s.hash, pubKeyHash) {
+				return s.key, nil
+			}
+		}
+		return nil, errors.New("no such key")
+	}
+}
+
+// newTestChain returns a chain of a genesis block listing signers
+func newTestChain(t *testing.T, signers ...*testSigner) *testChain {
+	t.Helper()
+
+	var hashes [][]byte
+	for _, s := range signers {
+		hashes = append(hashes, s.hash)
+	}
+
+	chain := &testChain{headers: make(map[string]*consensus.Header)}
+	genesis := &consensus.Header{Extra: GenesisExtra(hashes)}
+	engine := New(keyring())
+	if err := engine.Prepare(chain, genesis); err != nil {
+		t.Fatalf("Prepare genesis: %v", err)
+	}
+	if err := engine.Seal(context.Background(), genesis); err != nil {
+		t.Fatalf("Seal genesis: %v", err)
+	}
+	chain.add(genesis)
+
+	return chain
+}
+
+// nextHeader returns a header on top of the tip of chain
+func (c *testChain) nextHeader() *consensus.Header {
+	return &consensus.Header{
+		Timestamp: c.tip.Timestamp + 1,
+		PrevHash:  c.tip.Hash,
+		Height:    c.tip.Height + 1,
+	}
+}
+
+// sealNext seals and verifies the next block with engine and adds it to chain
+func sealNext(t *testing.T, engine *ProofOfAuthority, chain *testChain) *consensus.Header {
+	t.Helper()
+
+	header := chain.nextHeader()
+	if err := engine.Prepare(chain, header); err != nil {
+		t.Fatalf("Prepare height %d: %v", header.Height, err)
+	}
+	if err := engine.Seal(context.Background(), header); err != nil {
+		t.Fatalf("Seal height %d: %v", header.Height, err)
+	}
+	if err := New(keyring()).VerifySeal(chain, header); err != nil {
+		t.Fatalf("VerifySeal height %d: %v", header.Height, err)
+	}
+	chain.add(header)
+
+	return header
+}
+
+func TestSealInTurn(t *testing.T) {
+	signers := newTestSigners(t, 2)
+	chain := newTestChain(t, signers...)
+
+	// each node only seals the blocks of its turn
+	nodes := []*ProofOfAuthority{New(keyring(signers[0])), New(keyring(signers[1]))}
+	for i := 0; i < 4; i++ {
+		var inTurn []*ProofOfAuthority
+		for _, node := range nodes {
+			err := node.Prepare(chain, chain.nextHeader())
+			if errors.Is(err, consensus.ErrorNotInTurn) {
+				continue
+			}
+			if err != nil {
+				t.Fatalf("Prepare: %v", err)
+			}
+			inTurn = append(inTurn, node)
+		}
+		if len(inTurn) != 1 {
+			t.Fatalf("height %d: %d nodes in turn, want 1", chain.tip.Height+1, len(inTurn))
+		}
+		sealNext(t, inTurn[0], chain)
+	}
+}
+
+func TestVerifySealOutOfTurn(t *testing.T) {
+	signers := newTestSigners(t, 2)
+	chain := newTestChain(t, signers...)
+	verifier := New(keyring())
+
+	all, err := verifier.Signers(chain, chain.tip.Hash)
+	if err != nil {
+		t.Fatalf("Signers: %v", err)
+	}
+	outOfTurn := signers[0]
+	if bytes.Equal(all[1%len(all)], outOfTurn.hash) {
+		outOfTurn = signers[1]
+	}
+	outsider := newTestSigners(t, 1)[0]
+
+	for _, tc := range []struct {
+		name   string
+		signer *testSigner
+		want   error
+	}{
+		{"out of turn", outOfTurn, ErrorOutOfTurn},
+		{"unauthorized", outsider, ErrorUnauthorized},
+	} {
+		// a keyring handing out the key of signer whoever's turn it is
+		engine := New(func([]byte) (crypto.Signer, error) { return tc.signer.key, nil })
+
+		header := chain.nextHeader()
+		if err := engine.Prepare(chain, header); err != nil {
+			t.Fatalf("%s: Prepare: %v", tc.name, err)
+		}
+		if err := engine.Seal(context.Background(), header); err != nil {
+			t.Fatalf("%s: Seal: %v", tc.name, err)
+		}
+		if err := verifier.VerifySeal(chain, header); !errors.Is(err, tc.want) {
+			t.Errorf("%s: VerifySeal = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	// a valid seal no longer verifies once the header changes
+	header := sealNext(t, New(keyring(signers...)), chain)
+	header.Timestamp++
+	header.Hash = blockHash(header)
+	if err := verifier.VerifySeal(chain, header); !errors.Is(err, consensus.ErrorSealInvalid) {
+		t.Errorf("altered header: VerifySeal = %v, want %v", err, consensus.ErrorSealInvalid)
+	}
+}
+
+func TestVoteSigners(t *testing.T) {
+	signers := newTestSigners(t, 3)
+	chain := newTestChain(t, signers[:2]...)
+	engine := New(keyring(signers...))
+
+	isSigner := func(s *testSigner) bool {
+		all, err := engine.Signers(chain, chain.tip.Hash)
+		if err != nil {
+			t.Fatalf("Signers: %v", err)
+		}
+		for _, hash := range all {
+			if bytes.Equal(hash, s.hash) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// adding a signer to two needs the votes of both
+	engine.Propose(signers[2].hash, VoteAdd)
+	sealNext(t, engine, chain)
+	if isSigner(signers[2]) {
+		t.Fatal("signer added after 1 of 2 votes")
+	}
+	sealNext(t, engine, chain)
+	if !isSigner(signers[2]) {
+		t.Fatal("signer not added after 2 of 2 votes")
+	}
+
+	// the proposal passed, the next block carries no vote
+	header := sealNext(t, engine, chain)
+	if s, err := decodeSeal(header.Extra); err != nil || s.Vote != VoteNone {
+		t.Fatalf("seal after the vote passed: %+v, %v", s, err)
+	}
+
+	// removing one of three needs two votes, then it is out of the rotation
+	engine.Propose(signers[0].hash, VoteRemove)
+	removed := false
+	for i := 0; i < 6 && !removed; i++ {
+		sealNext(t, engine, chain)
+		removed = !isSigner(signers[0])
+	}
+	if !removed {
+		t.Fatal("signer not removed")
+	}
+	engine.Propose(signers[0].hash, VoteNone)
+
+	excluded := New(keyring(signers[0]))
+	if err := excluded.Prepare(chain, chain.nextHeader()); !errors.Is(err, consensus.ErrorNotInTurn) {
+		t.Errorf("removed signer: Prepare = %v, want %v", err, consensus.ErrorNotInTurn)
+	}
+}
@@ -0,0 +1,126 @@
+package poa
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+)
+
+// snapshot is the signer set and the pending votes after a block
+type snapshot struct {
+	signers [][]byte
+	// votes[target][voter] is the latest vote of voter on target
+	votes map[string]map[string]VoteKind
+}
+
+func newSnapshot(signers [][]byte) *snapshot {
+	s := &snapshot{votes: make(map[string]map[string]VoteKind)}
+	for _, signer := range signers {
+		if !s.isSigner(signer) {
+			s.signers = append(s.signers, signer)
+		}
+	}
+	s.sort()
+
+	return s
+}
+
+func (s *snapshot) copy() *snapshot {
+	c := &snapshot{
+		signers: append([][]byte{}, s.signers...),
+		votes:   make(map[string]map[string]VoteKind, len(s.votes)),
+	}
+	for target, voters := range s.votes {
+		c.votes[target] = make(map[string]VoteKind, len(voters))
+		for voter, vote := range voters {
+			c.votes[target][voter] = vote
+		}
+	}
+
+	return c
+}
+
+func (s *snapshot) sort() {
+	sort.Slice(s.signers, func(i, j int) bool {
+		return bytes.Compare(s.signers[i], s.signers[j]) < 0
+	})
+}
+
+func (s *snapshot) isSigner(pubKeyHash []byte) bool {
+	for _, signer := range s.signers {
+		if bytes.Equal(signer, pubKeyHash) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// inTurn returns the signer whose turn it is to seal the block at height
+func (s *snapshot) inTurn(height int) []byte {
+	return s.signers[height%len(s.signers)]
+}
+
+// validVote reports whether a vote would change the signer set
+func (s *snapshot) validVote(vote VoteKind, target []byte) bool {
+	switch vote {
+	case VoteAdd:
+		return !s.isSigner(target)
+	case VoteRemove:
+		// the last signer can't be removed, nobody could seal anymore
+		return s.isSigner(target) && len(s.signers) > 1
+	default:
+		return true
+	}
+}
+
+// apply records the vote of signer and changes the signer set once
+// more than half of the signers agree
+func (s *snapshot) apply(signer []byte, vote VoteKind, target []byte) error {
+	if vote == VoteNone {
+		return nil
+	}
+	if !s.validVote(vote, target) {
+		return errors.New("vote does not change the signer set")
+	}
+
+	voters, ok := s.votes[string(target)]
+	if !ok {
+		voters = make(map[string]VoteKind)
+		s.votes[string(target)] = voters
+	}
+	voters[string(signer)] = vote
+
+	tally := 0
+	for _, v := range voters {
+		if v == vote {
+			tally++
+		}
+	}
+	if tally <= len(s.signers)/2 {
+		return nil
+	}
+
+	delete(s.votes, string(target))
+	if vote == VoteAdd {
+		s.signers = append(s.signers, target)
+		s.sort()
+		return nil
+	}
+
+	for i, signer := range s.signers {
+		if bytes.Equal(signer, target) {
+			s.signers = append(s.signers[:i], s.signers[i+1:]...)
+			break
+		}
+	}
+	// votes of a removed signer no longer count
+	for t, voters := range s.votes {
+		delete(voters, string(target))
+		if len(voters) == 0 {
+			delete(s.votes, t)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package pow
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Algorithm is the hash function proofs of work are computed with. Block
+// hashes stay the SHA-256 seal hash whatever the algorithm, only the value
+// compared with the target changes.
+type Algorithm byte
+
+const (
+	SHA256 Algorithm = iota
+	// Scrypt uses the parameters of Litecoin, N=1024, r=1, p=1
+	Scrypt
+	// Argon2id uses one pass over 4 MiB
+	Argon2id
+)
+
+const (
+	scryptN = 1024
+	scryptR = 1
+	scryptP = 1
+
+	argon2Time    = 1
+	argon2Memory  = 4 * 1024 // KiB
+	argon2Threads = 1
+
+	powHashSize = 32
+)
+
+func (a Algorithm) String() string {
+	switch a {
+	case SHA256:
+		return "sha256"
+	case Scrypt:
+		return "scrypt"
+	case Argon2id:
+		return "argon2id"
+	default:
+		return fmt.Sprintf("unknown(%d)", byte(a))
+	}
+}
+
+// Hash returns the proof of work hash of data, the seal data of a header.
+// The memory hard functions use data as salt too, like Litecoin does.
+func (a Algorithm) Hash(data []byte) []byte {
+	switch a {
+	case Scrypt:
+		// only fails on invalid parameters
+		hash, err := scrypt.Key(data, data, scryptN, scryptR, scryptP, powHashSize)
+		if err != nil {
+			panic(err)
+		}
+		return hash
+	case Argon2id:
+		return argon2.IDKey(data, data, argon2Time, argon2Memory, argon2Threads, powHashSize)
+	default:
+		hash := sha256.Sum256(data)
+		return hash[:]
+	}
+}
+
+// ParseAlgorithm returns the algorithm with the given name
+func ParseAlgorithm(name string) (Algorithm, error) {
+	for _, a := range []Algorithm{SHA256, Scrypt, Argon2id} {
+		if a.String() == name {
+			return a, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown proof of work algorithm: %s", name)
+}
@@ -0,0 +1,227 @@
+package pow
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"runtime"
+	"sync"
+	"time"
+
+	"blockchain/pkg/consensus"
+)
+
+var _ consensus.Engine = (*ProofOfWork)(nil)
+
+var errExtra = errors.New("proof of work blocks carry no extra data")
+
+// DefaultMaxNonce bounds the nonces tried for one timestamp, nonces are
+// ints so it fits them on 32 bit platforms too
+const DefaultMaxNonce = math.MaxInt32
+
+// ProofOfWork seals blocks by searching a nonce that brings the proof of work
+// hash of the header below the target given by the difficulty, the number of
+// leading zero bits.
+type ProofOfWork struct {
+	difficulty int
+	algorithm  Algorithm
+	workers    int
+	maxNonce   int
+}
+
+type Opt func(*ProofOfWork)
+
+// WithAlgorithm sets the proof of work hash function, SHA-256 by default
+func WithAlgorithm(algorithm Algorithm) Opt {
+	return func(pow *ProofOfWork) {
+		pow.algorithm = algorithm
+	}
+}
+
+// WithWorkers sets the number of goroutines Seal searches with, one per CPU by default
+func WithWorkers(workers int) Opt {
+	return func(pow *ProofOfWork) {
+		if workers > 0 {
+			pow.workers = workers
+		}
+	}
+}
+
+// WithMaxNonce sets the size of the nonce space searched before the timestamp is rolled
+func WithMaxNonce(maxNonce int) Opt {
+	return func(pow *ProofOfWork) {
+		if maxNonce > 0 {
+			pow.maxNonce = maxNonce
+		}
+	}
+}
+
+func New(difficulty int, opts ...Opt) *ProofOfWork {
+	pow := &ProofOfWork{
+		difficulty: difficulty,
+		algorithm:  SHA256,
+		workers:    runtime.NumCPU(),
+		maxNonce:   DefaultMaxNonce,
+	}
+	for _, opt := range opts {
+		opt(pow)
+	}
+
+	return pow
+}
+
+func (pow *ProofOfWork) Prepare(chain consensus.ChainReader, header *consensus.Header) error {
+	if len(header.Extra) != 0 {
+		return errExtra
+	}
+
+	var parent *consensus.Header
+	if header.Height > 0 {
+		p, err := chain.GetHeader(header.PrevHash)
+		if err != nil {
+			return fmt.Errorf("%w: %s", consensus.ErrorUnknownParent, err)
+		}
+		parent = p
+	}
+
+	header.Difficulty = pow.CalcDifficulty(chain, parent)
+
+	return nil
+}
+
+// Seal searches the proof of work with all cores until it is found or ctx is done
+func (pow *ProofOfWork) Seal(ctx context.Context, header *consensus.Header) error {
+	return pow.Run(ctx, header, pow.workers)
+}
+
+// Run splits the nonce space between workers goroutines, each trying every
+// workers-th nonce. When the whole space is searched the timestamp is moved
+// forward and the search starts over. Run returns ctx.Err() once ctx is done.
+func (pow *ProofOfWork) Run(ctx context.Context, header *consensus.Header, workers int) error {
+	if workers <= 0 {
+		workers = 1
+	}
+	target := Target(header.Difficulty)
+
+	for {
+		if found, err := pow.search(ctx, header, workers, target); err != nil || found {
+			return err
+		}
+
+		// nonce space exhausted, a new timestamp gives a new one
+		now := time.Now().Unix()
+		if now <= header.Timestamp {
+			now = header.Timestamp + 1
+		}
+		header.Timestamp = now
+	}
+}
+
+func (pow *ProofOfWork) search(ctx context.Context, header *consensus.Header, workers int, target *big.Int) (bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan consensus.Header, workers)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(start int) {
+			defer wg.Done()
+
+			var intHash big.Int
+			h := *header
+			done := ctx.Done()
+			for nonce := start; nonce <= pow.maxNonce; nonce += workers {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				h.Nonce = nonce
+				intHash.SetBytes(pow.algorithm.Hash(h.SealData()))
+				if intHash.Cmp(target) == -1 {
+					results <- h
+					cancel()
+					return
+				}
+
+				// the next nonce would overflow past math.MaxInt
+				if pow.maxNonce-nonce < workers {
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	select {
+	case h := <-results:
+		header.Nonce = h.Nonce
+		header.Hash = header.SealHash()
+		return true, nil
+	default:
+		return false, ctx.Err()
+	}
+}
+
+func (pow *ProofOfWork) VerifySeal(chain consensus.ChainReader, header *consensus.Header) error {
+	// the hash does not cover Extra, it has to stay empty
+	if len(header.Extra) != 0 {
+		return errExtra
+	}
+
+	var parent *consensus.Header
+	if header.Height > 0 {
+		p, err := chain.GetHeader(header.PrevHash)
+		if err != nil {
+			return fmt.Errorf("%w: %s", consensus.ErrorUnknownParent, err)
+		}
+		parent = p
+	}
+
+	if expected := pow.CalcDifficulty(chain, parent); header.Difficulty != expected {
+		return fmt.Errorf("%w: got %d, expected %d", consensus.ErrorDifficultyInvalid, header.Difficulty, expected)
+	}
+
+	if !Validate(header, pow.algorithm) {
+		return consensus.ErrorSealInvalid
+	}
+
+	return nil
+}
+
+// Algorithm returns the hash function proofs of work are computed with
+func (pow *ProofOfWork) Algorithm() Algorithm {
+	return pow.algorithm
+}
+
+// CalcDifficulty returns the fixed difficulty of the engine
+func (pow *ProofOfWork) CalcDifficulty(_ consensus.ChainReader, _ *consensus.Header) int {
+	return pow.difficulty
+}
+
+// Validate reports whether the hash of header is correct and its proof of
+// work hash with algorithm is below its target
+func Validate(header *consensus.Header, algorithm Algorithm) bool {
+	var intHash big.Int
+
+	if !bytes.Equal(header.SealHash(), header.Hash) {
+		return false
+	}
+	intHash.SetBytes(algorithm.Hash(header.SealData()))
+
+	return intHash.Cmp(Target(header.Difficulty)) == -1
+}
+
+// Target returns the value block hashes must stay below for a difficulty
+func Target(difficulty int) *big.Int {
+	target := big.NewInt(1)
+	target.Lsh(target, uint(256-difficulty))
+
+	return target
+}
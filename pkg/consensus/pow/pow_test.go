@@ -0,0 +1,132 @@
+package pow
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"blockchain/pkg/consensus"
+)
+
+// testHeader returns a genesis header, which needs no chain to prepare or verify
+func testHeader() *consensus.Header {
+	return &consensus.Header{
+		Timestamp: 1700000000,
+		TxRoot:    []byte("transactions of the test block"),
+	}
+}
+
+func TestSealVerify(t *testing.T) {
+	pow := New(8, WithWorkers(2))
+
+	header := testHeader()
+	if err := pow.Prepare(nil, header); err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+	if header.Difficulty != 8 {
+		t.Fatalf("difficulty %d, want 8", header.Difficulty)
+	}
+	if err := pow.Seal(context.Background(), header); err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if err := pow.VerifySeal(nil, header); err != nil {
+		t.Fatalf("VerifySeal: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		mutate func(h *consensus.Header)
+		want   error
+	}{
+		{"hash", func(h *consensus.Header) { h.Hash = []byte("another hash") }, consensus.ErrorSealInvalid},
+		{"nonce", func(h *consensus.Header) { h.Nonce++; h.Hash = h.SealHash() }, consensus.ErrorSealInvalid},
+		{"difficulty", func(h *consensus.Header) { h.Difficulty = 4 }, consensus.ErrorDifficultyInvalid},
+		{"extra", func(h *consensus.Header) { h.Extra = []byte("extra") }, errExtra},
+	} {
+		h := *header
+		tc.mutate(&h)
+		// a changed nonce may still meet the target by chance
+		if tc.name == "nonce" && Validate(&h, SHA256) {
+			continue
+		}
+		if err := pow.VerifySeal(nil, &h); !errors.Is(err, tc.want) {
+			t.Errorf("%s: VerifySeal = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestSealAlgorithms(t *testing.T) {
+	for _, algorithm := range []Algorithm{SHA256, Scrypt, Argon2id} {
+		pow := New(4, WithAlgorithm(algorithm))
+
+		header := testHeader()
+		if err := pow.Prepare(nil, header); err != nil {
+			t.Fatalf("%s: Prepare: %v", algorithm, err)
+		}
+		if err := pow.Seal(context.Background(), header); err != nil {
+			t.Fatalf("%s: Seal: %v", algorithm, err)
+		}
+		if err := pow.VerifySeal(nil, header); err != nil {
+			t.Errorf("%s: VerifySeal: %v", algorithm, err)
+		}
+	}
+}
+
+func TestRunCancel(t *testing.T) {
+	// no hash is below the target of the largest difficulty
+	pow := New(256, WithWorkers(2))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	header := testHeader()
+	header.Difficulty = 256
+	if err := pow.Seal(ctx, header); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Seal = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if err := pow.Seal(ctx, header); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Seal with a done context = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestRunRollsTimestamp(t *testing.T) {
+	// 4 nonces per timestamp can't find a proof of 12 bits for long
+	const maxNonce = 3
+	pow := New(12, WithMaxNonce(maxNonce))
+
+	header := testHeader()
+	header.Difficulty = 12
+	start := header.Timestamp
+	if err := pow.Run(context.Background(), header, 2); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if header.Timestamp <= start {
+		t.Errorf("timestamp %d was not rolled past %d", header.Timestamp, start)
+	}
+	if header.Nonce < 0 || header.Nonce > maxNonce {
+		t.Errorf("nonce %d outside of 0 to %d", header.Nonce, maxNonce)
+	}
+	if !Validate(header, SHA256) {
+		t.Error("rolled header does not validate")
+	}
+}
+
+func TestRunWorkers(t *testing.T) {
+	// half of the nonces meet a difficulty of 1, workers find one at once
+	for _, workers := range []int{1, 4, 16} {
+		pow := New(1)
+
+		header := testHeader()
+		header.Difficulty = 1
+		if err := pow.Run(context.Background(), header, workers); err != nil {
+			t.Fatalf("%d workers: Run: %v", workers, err)
+		}
+		if !Validate(header, SHA256) {
+			t.Errorf("%d workers: header does not validate", workers)
+		}
+	}
+}
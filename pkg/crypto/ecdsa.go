@@ -0,0 +1,216 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	secp256k1ecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// Curve identifies the elliptic curve of an ECDSA key. It follows the
+// KeyType byte in serialized ECDSA public keys.
+type Curve byte
+
+const (
+	CurveP256 Curve = iota + 1
+	CurveSecp256k1
+)
+
+func (c Curve) String() string {
+	switch c {
+	case CurveP256:
+		return "p256"
+	case CurveSecp256k1:
+		return "secp256k1"
+	default:
+		return fmt.Sprintf("unknown(%d)", byte(c))
+	}
+}
+
+func (c Curve) elliptic() (elliptic.Curve, error) {
+	switch c {
+	case CurveP256:
+		return elliptic.P256(), nil
+	case CurveSecp256k1:
+		return secp256k1.S256(), nil
+	default:
+		return nil, fmt.Errorf("unknown curve %d", byte(c))
+	}
+}
+
+func curveOf(c elliptic.Curve) Curve {
+	if c == secp256k1.S256() {
+		return CurveSecp256k1
+	}
+
+	return CurveP256
+}
+
+const ecdsaScalarSize = 32
+
+// ECDSAPrivateKey signs with ECDSA. Signatures are r||s, each padded to 32 bytes,
+// with s normalized to the lower half of the curve order so they are not malleable.
+// Signing is deterministic, signing the same digest twice gives the same
+// signature, and left to the constant time implementations of the standard
+// library for P-256 and of decred for secp256k1.
+type ECDSAPrivateKey struct {
+	Key *ecdsa.PrivateKey
+	// Rand, when set, makes P-256 signatures randomized with entropy read
+	// from it. secp256k1 signatures are always deterministic.
+	Rand io.Reader
+	// Legacy keys serialize their public key untagged, see ECDSAPublicKey
+	Legacy bool
+}
+
+type ECDSAPublicKey struct {
+	Key *ecdsa.PublicKey
+	// Legacy keys serialize as X followed by Y, the way P-256 keys were
+	// serialized before key types, so the public key hashes and addresses
+	// of wallets created back then stay the same
+	Legacy bool
+}
+
+func GenerateECDSAKey(c Curve) (*ECDSAPrivateKey, error) {
+	ec, err := c.elliptic()
+	if err != nil {
+		return nil, err
+	}
+
+	priv, err := ecdsa.GenerateKey(ec, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ECDSAPrivateKey{Key: priv}, nil
+}
+
+func (k *ECDSAPrivateKey) Type() KeyType {
+	return KeyECDSA
+}
+
+func (k *ECDSAPrivateKey) Sign(digest []byte) ([]byte, error) {
+	if curveOf(k.Key.Curve) == CurveSecp256k1 {
+		priv := secp256k1.PrivKeyFromBytes(k.Key.D.FillBytes(make([]byte, ecdsaScalarSize)))
+		defer priv.Zero()
+
+		// the compact signature is a recovery code followed by r||s, with a low s
+		return secp256k1ecdsa.SignCompact(priv, digest, true)[1:], nil
+	}
+
+	der, err := signP256(k.Key, digest, k.Rand)
+	if err != nil {
+		return nil, err
+	}
+
+	var sig struct{ R, S *big.Int }
+	if rest, err := asn1.Unmarshal(der, &sig); err != nil || len(rest) > 0 {
+		return nil, errors.New("invalid ecdsa signature encoding")
+	}
+
+	// s and n-s are both valid, only the low one is accepted
+	n := k.Key.Curve.Params().N
+	if sig.S.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		sig.S.Sub(n, sig.S)
+	}
+
+	signature := make([]byte, 2*ecdsaScalarSize)
+	sig.R.FillBytes(signature[:ecdsaScalarSize])
+	sig.S.FillBytes(signature[ecdsaScalarSize:])
+
+	return signature, nil
+}
+
+func (k *ECDSAPrivateKey) Public() Verifier {
+	return &ECDSAPublicKey{Key: &k.Key.PublicKey, Legacy: k.Legacy}
+}
+
+func (k *ECDSAPublicKey) Type() KeyType {
+	return KeyECDSA
+}
+
+// Verify only accepts fixed width signatures with 0 < r < n and 0 < s <= n/2
+func (k *ECDSAPublicKey) Verify(digest, signature []byte) bool {
+	var rInt, sInt big.Int
+
+	if len(signature) != 2*ecdsaScalarSize {
+		return false
+	}
+	rInt.SetBytes(signature[:ecdsaScalarSize])
+	sInt.SetBytes(signature[ecdsaScalarSize:])
+
+	n := k.Key.Curve.Params().N
+	if rInt.Sign() == 0 || rInt.Cmp(n) >= 0 || sInt.Sign() == 0 || sInt.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		return false
+	}
+
+	return ecdsa.Verify(k.Key, digest, &rInt, &sInt)
+}
+
+// Curve returns the curve the key lies on
+func (k *ECDSAPublicKey) Curve() Curve {
+	return curveOf(k.Key.Curve)
+}
+
+// Bytes returns the curve followed by the compressed SEC1 encoding of the
+// key, or the untagged coordinates of a legacy key
+func (k *ECDSAPublicKey) Bytes() []byte {
+	if k.Legacy {
+		return append(k.Key.X.Bytes(), k.Key.Y.Bytes()...)
+	}
+
+	key := elliptic.MarshalCompressed(k.Key.Curve, k.Key.X, k.Key.Y)
+
+	return tagKey(KeyECDSA, append([]byte{byte(k.Curve())}, key...))
+}
+
+func parseECDSAPublicKey(data []byte) (*ECDSAPublicKey, error) {
+	if len(data) != 1+1+ecdsaScalarSize {
+		return nil, errors.New("invalid ecdsa public key")
+	}
+
+	switch Curve(data[0]) {
+	case CurveP256:
+		// UnmarshalCompressed rejects any other prefix,
+		// coordinates out of range and points off the curve
+		x, y := elliptic.UnmarshalCompressed(elliptic.P256(), data[1:])
+		if x == nil {
+			return nil, errors.New("invalid ecdsa public key")
+		}
+		return &ECDSAPublicKey{Key: &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}}, nil
+	case CurveSecp256k1:
+		// the length check above leaves only the compressed format
+		pub, err := secp256k1.ParsePubKey(data[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid ecdsa public key: %w", err)
+		}
+		return &ECDSAPublicKey{Key: pub.ToECDSA()}, nil
+	default:
+		return nil, fmt.Errorf("invalid ecdsa public key: unknown curve %d", data[0])
+	}
+}
+
+// parseLegacyECDSAPublicKey decodes an untagged P-256 key, its coordinates
+// stripped of leading zeros and split in the middle as they always were
+func parseLegacyECDSAPublicKey(data []byte) (*ECDSAPublicKey, error) {
+	x := new(big.Int).SetBytes(data[:len(data)/2])
+	y := new(big.Int).SetBytes(data[len(data)/2:])
+	if !elliptic.P256().IsOnCurve(x, y) {
+		return nil, errors.New("invalid legacy ecdsa public key")
+	}
+
+	key := &ECDSAPublicKey{Key: &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, Legacy: true}
+	// one key has one encoding, or it could be spent with several hashes
+	if !bytes.Equal(key.Bytes(), data) {
+		return nil, errors.New("invalid legacy ecdsa public key")
+	}
+
+	return key, nil
+}
@@ -0,0 +1,193 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// ecdsaTestKey returns the ECDSA key of curve c with the hex private scalar d
+func ecdsaTestKey(t *testing.T, c Curve, d string) *ECDSAPrivateKey {
+	t.Helper()
+
+	ec, err := c.elliptic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	scalar, ok := new(big.Int).SetString(d, 16)
+	if !ok {
+		t.Fatalf("invalid private key %s", d)
+	}
+
+	key := &ecdsa.PrivateKey{D: scalar}
+	key.Curve = ec
+	key.X, key.Y = ec.ScalarBaseMult(scalar.FillBytes(make([]byte, ecdsaScalarSize)))
+
+	return &ECDSAPrivateKey{Key: key}
+}
+
+func TestECDSASecp256k1Vectors(t *testing.T) {
+	// the RFC 6979 secp256k1 vectors of python-ecdsa and Trezor
+	for _, tc := range []struct {
+		d, message, signature string
+	}{
+		{
+			d:         "1",
+			message:   "Satoshi Nakamoto",
+			signature: "934b1ea10a4b3c1757e2b0c017d0b6143ce3c9a7e6a4a49860d7a6ab210ee3d8" + "2442ce9d2b916064108014783e923ec36b49743e2ffa1c4496f01a512aafd9e5",
+		},
+		{
+			d:         "1",
+			message:   "All those moments will be lost in time, like tears in rain. Time to die...",
+			signature: "8600dbd41e348fe5c9465ab92d23e3db8b98b873beecd930736488696438cb6b" + "547fe64427496db33bf66019dacbf0039c04199abb0122918601db38a72cfc21",
+		},
+	} {
+		key := ecdsaTestKey(t, CurveSecp256k1, tc.d)
+		digest := sha256.Sum256([]byte(tc.message))
+
+		signature, err := key.Sign(digest[:])
+		if err != nil {
+			t.Fatalf("%q: Sign: %v", tc.message, err)
+		}
+		if got := hex.EncodeToString(signature); got != tc.signature {
+			t.Errorf("%q: signature %s, want %s", tc.message, got, tc.signature)
+		}
+		if !key.Public().Verify(digest[:], signature) {
+			t.Errorf("%q: signature does not verify", tc.message)
+		}
+	}
+}
+
+func TestECDSASign(t *testing.T) {
+	for _, c := range []Curve{CurveP256, CurveSecp256k1} {
+		key, err := GenerateECDSAKey(c)
+		if err != nil {
+			t.Fatalf("%s: GenerateECDSAKey: %v", c, err)
+		}
+		pub := key.Public()
+		halfOrder := new(big.Int).Rsh(key.Key.Curve.Params().N, 1)
+
+		for i := 0; i < 32; i++ {
+			digest := sha256.Sum256([]byte{byte(i)})
+
+			signature, err := key.Sign(digest[:])
+			if err != nil {
+				t.Fatalf("%s: Sign: %v", c, err)
+			}
+			again, err := key.Sign(digest[:])
+			if err != nil {
+				t.Fatalf("%s: Sign: %v", c, err)
+			}
+			if !bytes.Equal(signature, again) {
+				t.Fatalf("%s: signing twice gave different signatures", c)
+			}
+
+			if len(signature) != 2*ecdsaScalarSize {
+				t.Fatalf("%s: signature is %d bytes", c, len(signature))
+			}
+			if new(big.Int).SetBytes(signature[ecdsaScalarSize:]).Cmp(halfOrder) > 0 {
+				t.Fatalf("%s: signature has a high s", c)
+			}
+			if !pub.Verify(digest[:], signature) {
+				t.Fatalf("%s: signature does not verify", c)
+			}
+
+			digest[0] ^= 1
+			if pub.Verify(digest[:], signature) {
+				t.Fatalf("%s: signature verifies another digest", c)
+			}
+		}
+	}
+}
+
+func TestECDSAExtraEntropy(t *testing.T) {
+	key, err := GenerateECDSAKey(CurveP256)
+	if err != nil {
+		t.Fatalf("GenerateECDSAKey: %v", err)
+	}
+	WithExtraEntropy(key, rand.Reader)
+
+	digest := sha256.Sum256([]byte("message"))
+	first, err := key.Sign(digest[:])
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	second, err := key.Sign(digest[:])
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	if bytes.Equal(first, second) {
+		t.Error("randomized signatures are equal")
+	}
+	for _, signature := range [][]byte{first, second} {
+		if !key.Public().Verify(digest[:], signature) {
+			t.Error("randomized signature does not verify")
+		}
+	}
+}
+
+func TestECDSASecp256k1MatchesDecred(t *testing.T) {
+	priv, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("GeneratePrivateKey: %v", err)
+	}
+	key := &ECDSAPrivateKey{Key: priv.ToECDSA()}
+
+	digest := sha256.Sum256([]byte("message"))
+	signature, err := key.Sign(digest[:])
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	pub, err := ParsePublicKey(key.Public().Bytes())
+	if err != nil {
+		t.Fatalf("ParsePublicKey: %v", err)
+	}
+	if !pub.Verify(digest[:], signature) {
+		t.Error("signature does not verify against the parsed public key")
+	}
+}
+
+func TestECDSALegacyPublicKey(t *testing.T) {
+	key, err := GenerateECDSAKey(CurveP256)
+	if err != nil {
+		t.Fatalf("GenerateECDSAKey: %v", err)
+	}
+	key.Legacy = true
+	pub := key.Public()
+
+	// the encoding of P-256 keys before key types
+	data := pub.Bytes()
+	if want := append(key.Key.X.Bytes(), key.Key.Y.Bytes()...); !bytes.Equal(data, want) {
+		t.Fatalf("legacy key is %x, want %x", data, want)
+	}
+
+	parsed, err := ParsePublicKey(data)
+	if err != nil {
+		t.Fatalf("ParsePublicKey: %v", err)
+	}
+	if !bytes.Equal(parsed.Bytes(), data) {
+		t.Errorf("parsed key is %x, want %x", parsed.Bytes(), data)
+	}
+
+	digest := sha256.Sum256([]byte("message"))
+	signature, err := key.Sign(digest[:])
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if !Verify(data, digest[:], signature) {
+		t.Error("signature does not verify against the legacy key")
+	}
+
+	padded := append(make([]byte, 2), data...)
+	if _, err := ParsePublicKey(padded); err == nil {
+		t.Error("parsed a legacy key with a second encoding")
+	}
+}
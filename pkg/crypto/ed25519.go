@@ -0,0 +1,56 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+)
+
+type Ed25519PrivateKey struct {
+	Key ed25519.PrivateKey
+}
+
+type Ed25519PublicKey struct {
+	Key ed25519.PublicKey
+}
+
+func GenerateEd25519Key() (*Ed25519PrivateKey, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Ed25519PrivateKey{Key: priv}, nil
+}
+
+func (k *Ed25519PrivateKey) Type() KeyType {
+	return KeyEd25519
+}
+
+func (k *Ed25519PrivateKey) Sign(digest []byte) ([]byte, error) {
+	return ed25519.Sign(k.Key, digest), nil
+}
+
+func (k *Ed25519PrivateKey) Public() Verifier {
+	return &Ed25519PublicKey{Key: k.Key.Public().(ed25519.PublicKey)}
+}
+
+func (k *Ed25519PublicKey) Type() KeyType {
+	return KeyEd25519
+}
+
+func (k *Ed25519PublicKey) Verify(digest, signature []byte) bool {
+	return len(signature) == ed25519.SignatureSize && ed25519.Verify(k.Key, digest, signature)
+}
+
+func (k *Ed25519PublicKey) Bytes() []byte {
+	return tagKey(KeyEd25519, k.Key)
+}
+
+func parseEd25519PublicKey(data []byte) (*Ed25519PublicKey, error) {
+	if len(data) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid ed25519 public key")
+	}
+
+	return &Ed25519PublicKey{Key: ed25519.PublicKey(data)}, nil
+}
@@ -0,0 +1,69 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"testing"
+)
+
+func TestEd25519Vector(t *testing.T) {
+	// test 1 of RFC 8032, signing an empty message
+	seed := decodeHex(t, "9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	pubKey := decodeHex(t, "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a")
+	want := decodeHex(t, "e5564300c360ac729086e2cc806e828a84877f1eb8e5d974d873e065224901555fb8821590a33bacc61e39701cf9b46bd25bf5f0595bbe24655141438e7a100b")
+
+	key := &Ed25519PrivateKey{Key: ed25519.NewKeyFromSeed(seed)}
+	if got := key.Public().Bytes(); !bytes.Equal(got, tagKey(KeyEd25519, pubKey)) {
+		t.Errorf("public key %x, want %x", got, pubKey)
+	}
+
+	signature, err := key.Sign(nil)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if !bytes.Equal(signature, want) {
+		t.Errorf("signature %x, want %x", signature, want)
+	}
+}
+
+func TestEd25519PublicKey(t *testing.T) {
+	key, err := GenerateKey(KeyEd25519)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	digest := []byte("digest of a transaction to sign")
+	signature, err := key.Sign(digest)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	data := key.Public().Bytes()
+	pub, err := ParsePublicKey(data)
+	if err != nil {
+		t.Fatalf("ParsePublicKey: %v", err)
+	}
+	if pub.Type() != KeyEd25519 {
+		t.Errorf("parsed key type %s, want %s", pub.Type(), KeyEd25519)
+	}
+	if !bytes.Equal(pub.Bytes(), data) {
+		t.Errorf("parsed key is %x, want %x", pub.Bytes(), data)
+	}
+	if !pub.Verify(digest, signature) {
+		t.Error("signature does not verify against the parsed key")
+	}
+
+	if pub.Verify(digest, signature[:len(signature)-1]) {
+		t.Error("truncated signature verifies")
+	}
+	signature[0] ^= 1
+	if pub.Verify(digest, signature) {
+		t.Error("altered signature verifies")
+	}
+
+	for _, bad := range [][]byte{data[:len(data)-1], append(append([]byte{}, data...), 0)} {
+		if _, err := ParsePublicKey(bad); err == nil {
+			t.Errorf("parsed an ed25519 key of %d bytes", len(bad)-1)
+		}
+	}
+}
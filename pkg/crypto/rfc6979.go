@@ -0,0 +1,90 @@
+//go:build !go1.24
+
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha256"
+	"io"
+	"math/big"
+)
+
+// signP256 returns the ASN.1 signature of digest. Before Go 1.24 the
+// standard library can't derive RFC 6979 nonces, unless rand is set it is
+// handed the RFC 6979 nonce as the entropy of its own derivation instead,
+// which keeps signatures deterministic.
+func signP256(key *ecdsa.PrivateKey, digest []byte, rand io.Reader) ([]byte, error) {
+	if rand == nil {
+		rand = &rfc6979Reader{nonce: nonceRFC6979(key.Curve.Params().N, key.D, digest)}
+	}
+
+	return ecdsa.SignASN1(rand, key, digest)
+}
+
+// rfc6979Reader reads the bytes of a nonce, starting over on every Read so
+// the result does not depend on how many bytes were read before
+type rfc6979Reader struct {
+	nonce *big.Int
+}
+
+func (r *rfc6979Reader) Read(p []byte) (int, error) {
+	return copy(p, r.nonce.FillBytes(make([]byte, ecdsaScalarSize))), nil
+}
+
+// nonceRFC6979 derives the ECDSA nonce for digest from the private key as
+// described in RFC 6979 section 3.2, using HMAC-SHA256. The same key and
+// digest always give the same nonce.
+func nonceRFC6979(n, priv *big.Int, digest []byte) *big.Int {
+	qlen := n.BitLen()
+	rolen := (qlen + 7) / 8
+
+	x := priv.FillBytes(make([]byte, rolen))
+	h := new(big.Int).Mod(bits2int(digest, qlen), n).FillBytes(make([]byte, rolen))
+
+	v := make([]byte, sha256.Size)
+	for i := range v {
+		v[i] = 0x01
+	}
+	k := make([]byte, sha256.Size)
+
+	k = hmacSHA256(k, v, []byte{0x00}, x, h)
+	v = hmacSHA256(k, v)
+	k = hmacSHA256(k, v, []byte{0x01}, x, h)
+	v = hmacSHA256(k, v)
+
+	for {
+		var t []byte
+		for len(t) < rolen {
+			v = hmacSHA256(k, v)
+			t = append(t, v...)
+		}
+
+		nonce := bits2int(t, qlen)
+		if nonce.Sign() > 0 && nonce.Cmp(n) < 0 {
+			return nonce
+		}
+
+		k = hmacSHA256(k, v, []byte{0x00})
+		v = hmacSHA256(k, v)
+	}
+}
+
+// bits2int interprets the leftmost qlen bits of data as an integer
+func bits2int(data []byte, qlen int) *big.Int {
+	i := new(big.Int).SetBytes(data)
+	if excess := len(data)*8 - qlen; excess > 0 {
+		i.Rsh(i, uint(excess))
+	}
+
+	return i
+}
+
+func hmacSHA256(key []byte, data ...[]byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	for _, d := range data {
+		mac.Write(d)
+	}
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,197 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+const (
+	schnorrPublicKeySize = 32
+	schnorrSignatureSize = 64
+)
+
+// SchnorrPrivateKey signs with BIP340 Schnorr signatures over secp256k1.
+// Public keys are the 32 byte x coordinate, signatures are R.x||s.
+// Signing is deterministic unless Rand is set.
+type SchnorrPrivateKey struct {
+	Key *secp256k1.PrivateKey
+	// Rand, when set, is read for the auxiliary randomness of every signature
+	Rand io.Reader
+}
+
+type SchnorrPublicKey struct {
+	X [schnorrPublicKeySize]byte
+}
+
+func GenerateSchnorrKey() (*SchnorrPrivateKey, error) {
+	priv, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return &SchnorrPrivateKey{Key: priv}, nil
+}
+
+func (k *SchnorrPrivateKey) Type() KeyType {
+	return KeySchnorr
+}
+
+func (k *SchnorrPrivateKey) Sign(digest []byte) ([]byte, error) {
+	aux := make([]byte, 32)
+	if k.Rand != nil {
+		if _, err := io.ReadFull(k.Rand, aux); err != nil {
+			return nil, err
+		}
+	}
+
+	return schnorrSign(&k.Key.Key, digest, aux)
+}
+
+func (k *SchnorrPrivateKey) Public() Verifier {
+	var p secp256k1.JacobianPoint
+	secp256k1.ScalarBaseMultNonConst(&k.Key.Key, &p)
+	p.ToAffine()
+
+	return &SchnorrPublicKey{X: *p.X.Bytes()}
+}
+
+func (k *SchnorrPublicKey) Type() KeyType {
+	return KeySchnorr
+}
+
+func (k *SchnorrPublicKey) Verify(digest, signature []byte) bool {
+	if len(signature) != schnorrSignatureSize {
+		return false
+	}
+
+	var p secp256k1.JacobianPoint
+	if !liftX(k.X[:], &p) {
+		return false
+	}
+
+	var r secp256k1.FieldVal
+	if r.SetByteSlice(signature[:32]) {
+		return false
+	}
+	var s secp256k1.ModNScalar
+	if s.SetByteSlice(signature[32:]) {
+		return false
+	}
+
+	e := schnorrChallenge(signature[:32], k.X[:], digest)
+
+	// R = s*G - e*P
+	var sG, eP, rPoint secp256k1.JacobianPoint
+	secp256k1.ScalarBaseMultNonConst(&s, &sG)
+	secp256k1.ScalarMultNonConst(e.Negate(), &p, &eP)
+	secp256k1.AddNonConst(&sG, &eP, &rPoint)
+
+	if (rPoint.X.IsZero() && rPoint.Y.IsZero()) || rPoint.Z.IsZero() {
+		return false
+	}
+	rPoint.ToAffine()
+
+	return !rPoint.Y.IsOdd() && rPoint.X.Equals(&r)
+}
+
+func (k *SchnorrPublicKey) Bytes() []byte {
+	return tagKey(KeySchnorr, k.X[:])
+}
+
+func parseSchnorrPublicKey(data []byte) (*SchnorrPublicKey, error) {
+	var p secp256k1.JacobianPoint
+	if len(data) != schnorrPublicKeySize || !liftX(data, &p) {
+		return nil, errors.New("invalid schnorr public key")
+	}
+
+	key := &SchnorrPublicKey{}
+	copy(key.X[:], data)
+
+	return key, nil
+}
+
+func schnorrSign(priv *secp256k1.ModNScalar, digest, aux []byte) ([]byte, error) {
+	// the secret is negated when needed so that its public key has an even y
+	var d secp256k1.ModNScalar
+	d.Set(priv)
+
+	var p secp256k1.JacobianPoint
+	secp256k1.ScalarBaseMultNonConst(&d, &p)
+	p.ToAffine()
+	if p.Y.IsOdd() {
+		d.Negate()
+	}
+	pubX := p.X.Bytes()
+
+	dBytes := d.Bytes()
+	t := taggedHash("BIP0340/aux", aux)
+	for i := range t {
+		t[i] ^= dBytes[i]
+	}
+
+	var k secp256k1.ModNScalar
+	k.SetBytes(taggedHash("BIP0340/nonce", t[:], pubX[:], digest))
+	if k.IsZero() {
+		return nil, errors.New("schnorr nonce is zero")
+	}
+
+	var r secp256k1.JacobianPoint
+	secp256k1.ScalarBaseMultNonConst(&k, &r)
+	r.ToAffine()
+	if r.Y.IsOdd() {
+		k.Negate()
+	}
+	rX := r.X.Bytes()
+
+	e := schnorrChallenge(rX[:], pubX[:], digest)
+	s := new(secp256k1.ModNScalar).Mul2(e, &d).Add(&k)
+
+	sBytes := s.Bytes()
+	signature := append(rX[:], sBytes[:]...)
+
+	return signature, nil
+}
+
+func schnorrChallenge(rX, pubX, digest []byte) *secp256k1.ModNScalar {
+	var e secp256k1.ModNScalar
+	e.SetBytes(taggedHash("BIP0340/challenge", rX, pubX, digest))
+
+	return &e
+}
+
+// liftX returns the point with x coordinate x and an even y
+func liftX(x []byte, result *secp256k1.JacobianPoint) bool {
+	var fx, fy secp256k1.FieldVal
+	if fx.SetByteSlice(x) {
+		return false
+	}
+	if !secp256k1.DecompressY(&fx, false, &fy) {
+		return false
+	}
+	fy.Normalize()
+
+	result.X.Set(&fx)
+	result.Y.Set(&fy)
+	result.Z.SetInt(1)
+
+	return true
+}
+
+func taggedHash(tag string, data ...[]byte) *[32]byte {
+	tagHash := sha256.Sum256([]byte(tag))
+
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, d := range data {
+		h.Write(d)
+	}
+
+	var sum [32]byte
+	copy(sum[:], h.Sum(nil))
+
+	return &sum
+}
@@ -0,0 +1,20 @@
+//go:build go1.24
+
+package crypto
+
+import (
+	stdcrypto "crypto"
+	"crypto/ecdsa"
+	"io"
+)
+
+// signP256 returns the ASN.1 signature of digest, with a nonce derived
+// with RFC 6979 unless rand is set
+func signP256(key *ecdsa.PrivateKey, digest []byte, rand io.Reader) ([]byte, error) {
+	if rand != nil {
+		return ecdsa.SignASN1(rand, key, digest)
+	}
+
+	// without a reader the standard library derives the nonce itself
+	return key.Sign(nil, digest, stdcrypto.SHA256)
+}
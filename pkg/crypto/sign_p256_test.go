@@ -0,0 +1,48 @@
+//go:build go1.24
+
+package crypto
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+func TestECDSAP256Vectors(t *testing.T) {
+	// RFC 6979 appendix A.2.5, P-256 with SHA-256
+	key := ecdsaTestKey(t, CurveP256, "c9afa9d845ba75166b5c215767b1d6934e50c3db36e89b127b8a622b120f6721")
+	n := key.Key.Curve.Params().N
+
+	for _, tc := range []struct {
+		message, r, s string
+	}{
+		{
+			message: "sample",
+			r:       "efd48b2aacb6a8fd1140dd9cd45e81d69d2c877b56aaf991c34d0ea84eaf3716",
+			s:       "f7cb1c942d657c41d436c7a1b6e29f65f3e900dbb9aff4064dc4ab2f843acda8",
+		},
+		{
+			message: "test",
+			r:       "f1abb023518351cd71d881567b1ea663ed3efcf6c5132b354f28d3b0b7d38367",
+			s:       "019f4113742a2b14bd25926b49c649155f267e60d3814b4c0cc84250e46f0083",
+		},
+	} {
+		digest := sha256.Sum256([]byte(tc.message))
+		signature, err := key.Sign(digest[:])
+		if err != nil {
+			t.Fatalf("%q: Sign: %v", tc.message, err)
+		}
+
+		// the vectors don't normalize s
+		s, _ := new(big.Int).SetString(tc.s, 16)
+		if s.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+			s.Sub(n, s)
+		}
+		want := tc.r + hex.EncodeToString(s.FillBytes(make([]byte, ecdsaScalarSize)))
+
+		if got := hex.EncodeToString(signature); got != want {
+			t.Errorf("%q: signature %s, want %s", tc.message, got, want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package crypto
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// KeyType identifies the signature scheme of a key. It is the first byte
+// of every serialized public key, so addresses commit to the scheme too.
+type KeyType byte
+
+const (
+	KeyECDSA KeyType = iota + 1
+	KeyEd25519
+	KeySchnorr
+)
+
+var ErrorUnknownKeyType = errors.New("unknown key type")
+
+func (t KeyType) String() string {
+	switch t {
+	case KeyECDSA:
+		return "ecdsa"
+	case KeyEd25519:
+		return "ed25519"
+	case KeySchnorr:
+		return "schnorr"
+	default:
+		return fmt.Sprintf("unknown(%d)", byte(t))
+	}
+}
+
+// ParseKeyType returns the key type with the given name
+func ParseKeyType(name string) (KeyType, error) {
+	for _, t := range []KeyType{KeyECDSA, KeyEd25519, KeySchnorr} {
+		if t.String() == name {
+			return t, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: %s", ErrorUnknownKeyType, name)
+}
+
+// Signer is a private key able to sign message digests
+type Signer interface {
+	Type() KeyType
+	Sign(digest []byte) ([]byte, error)
+	Public() Verifier
+}
+
+// Verifier is a public key able to check signatures made by its Signer
+type Verifier interface {
+	Type() KeyType
+	Verify(digest, signature []byte) bool
+	// Bytes returns the key prefixed with its KeyType
+	Bytes() []byte
+}
+
+type keyOptions struct {
+	curve Curve
+}
+
+type KeyOpt func(*keyOptions)
+
+// WithCurve selects the curve of ECDSA keys, P-256 is used by default
+func WithCurve(c Curve) KeyOpt {
+	return func(o *keyOptions) {
+		o.curve = c
+	}
+}
+
+// GenerateKey creates a new random private key of the given type
+func GenerateKey(keyType KeyType, opts ...KeyOpt) (Signer, error) {
+	o := &keyOptions{curve: CurveP256}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	switch keyType {
+	case KeyECDSA:
+		return GenerateECDSAKey(o.curve)
+	case KeyEd25519:
+		return GenerateEd25519Key()
+	case KeySchnorr:
+		return GenerateSchnorrKey()
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrorUnknownKeyType, byte(keyType))
+	}
+}
+
+// WithExtraEntropy makes key mix randomness read from rand into its signatures.
+// Signatures are deterministic without it, Ed25519 and secp256k1 ECDSA
+// signatures always are.
+func WithExtraEntropy(key Signer, rand io.Reader) {
+	switch k := key.(type) {
+	case *ECDSAPrivateKey:
+		k.Rand = rand
+	case *SchnorrPrivateKey:
+		k.Rand = rand
+	}
+}
+
+// ParsePublicKey decodes a public key serialized with Verifier.Bytes.
+// Untagged keys, longer than any tagged one, are legacy P-256 keys.
+func ParsePublicKey(data []byte) (Verifier, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty public key")
+	}
+	if len(data) > maxTaggedKeySize {
+		return parseLegacyECDSAPublicKey(data)
+	}
+
+	switch KeyType(data[0]) {
+	case KeyECDSA:
+		return parseECDSAPublicKey(data[1:])
+	case KeyEd25519:
+		return parseEd25519PublicKey(data[1:])
+	case KeySchnorr:
+		return parseSchnorrPublicKey(data[1:])
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrorUnknownKeyType, data[0])
+	}
+}
+
+// Verify checks signature of digest against a serialized public key
+func Verify(pub, digest, signature []byte) bool {
+	key, err := ParsePublicKey(pub)
+	if err != nil {
+		return false
+	}
+
+	return key.Verify(digest, signature)
+}
+
+// maxTaggedKeySize is the size of the largest tagged public key, an ECDSA
+// key of type, curve and compressed point
+const maxTaggedKeySize = 1 + 1 + 1 + ecdsaScalarSize
+
+func tagKey(keyType KeyType, key []byte) []byte {
+	return append([]byte{byte(keyType)}, key...)
+}
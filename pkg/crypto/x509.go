@@ -2,48 +2,85 @@ package crypto
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"fmt"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
 )
 
-func X509EncodePrivate(privateKey *ecdsa.PrivateKey) []byte {
-	x509EncodedPriv, err := x509.MarshalECPrivateKey(privateKey)
-	if err != nil {
-		panic(fmt.Errorf("failed to marshal private key, err: %w", err))
-	}
+const (
+	pemECDSAPrivate   = "PRIVATE KEY"
+	pemK256Private    = "SECP256K1 PRIVATE KEY"
+	pemEd25519Private = "ED25519 PRIVATE KEY"
+	pemSchnorrPrivate = "SCHNORR PRIVATE KEY"
+	pemPublic         = "PUBLIC KEY"
+)
 
-	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: x509EncodedPriv})
+// EncodePrivateKey returns the PEM encoding of a private key of any type
+func EncodePrivateKey(key Signer) ([]byte, error) {
+	switch k := key.(type) {
+	case *ECDSAPrivateKey:
+		// x509 has no encoding for secp256k1 keys
+		if curveOf(k.Key.Curve) == CurveSecp256k1 {
+			return pem.EncodeToMemory(&pem.Block{Type: pemK256Private, Bytes: k.Key.D.FillBytes(make([]byte, ecdsaScalarSize))}), nil
+		}
+		return X509EncodePrivate(k.Key), nil
+	case *Ed25519PrivateKey:
+		return pem.EncodeToMemory(&pem.Block{Type: pemEd25519Private, Bytes: k.Key.Seed()}), nil
+	case *SchnorrPrivateKey:
+		return pem.EncodeToMemory(&pem.Block{Type: pemSchnorrPrivate, Bytes: k.Key.Serialize()}), nil
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrorUnknownKeyType, key)
+	}
 }
 
-func X509DecodePrivate(pemEncodedPriv []byte) *ecdsa.PrivateKey {
-	block, _ := pem.Decode(pemEncodedPriv)
-	x509Encoded := block.Bytes
-	privateKey, err := x509.ParseECPrivateKey(x509Encoded)
-	if err != nil {
-		panic(fmt.Errorf("failed to parse private key, err: %w", err))
+// DecodePrivateKey parses a private key encoded with EncodePrivateKey
+func DecodePrivateKey(data []byte) (Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("failed to decode private key pem")
 	}
 
-	return privateKey
-}
-
-func X509EncodePublic(publicKey *ecdsa.PublicKey) []byte {
-	x509EncodedPub, err := x509.MarshalPKIXPublicKey(publicKey)
-	if err != nil {
-		panic(fmt.Errorf("failed to marshal public key, err: %w", err))
+	switch block.Type {
+	case pemECDSAPrivate:
+		priv, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key, err: %w", err)
+		}
+		return &ECDSAPrivateKey{Key: priv}, nil
+	case pemK256Private:
+		if len(block.Bytes) != secp256k1.PrivKeyBytesLen {
+			return nil, errors.New("invalid secp256k1 private key")
+		}
+		return &ECDSAPrivateKey{Key: secp256k1.PrivKeyFromBytes(block.Bytes).ToECDSA()}, nil
+	case pemEd25519Private:
+		if len(block.Bytes) != ed25519.SeedSize {
+			return nil, errors.New("invalid ed25519 private key")
+		}
+		return &Ed25519PrivateKey{Key: ed25519.NewKeyFromSeed(block.Bytes)}, nil
+	case pemSchnorrPrivate:
+		if len(block.Bytes) != secp256k1.PrivKeyBytesLen {
+			return nil, errors.New("invalid schnorr private key")
+		}
+		return &SchnorrPrivateKey{Key: secp256k1.PrivKeyFromBytes(block.Bytes)}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrorUnknownKeyType, block.Type)
 	}
+}
 
-	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: x509EncodedPub})
+// EncodePublicKey returns the PEM encoding of the tagged public key bytes
+func EncodePublicKey(key Verifier) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: pemPublic, Bytes: key.Bytes()})
 }
 
-func X509DecodePublic(pemEncodedPub []byte) *ecdsa.PublicKey {
-	block, _ := pem.Decode(pemEncodedPub)
-	x509Encoded := block.Bytes
-	genericPublicKey, err := x509.ParsePKIXPublicKey(x509Encoded)
+func X509EncodePrivate(privateKey *ecdsa.PrivateKey) []byte {
+	x509EncodedPriv, err := x509.MarshalECPrivateKey(privateKey)
 	if err != nil {
-		panic(fmt.Errorf("failed to parse public key, err: %w", err))
+		panic(fmt.Errorf("failed to marshal private key, err: %w", err))
 	}
-	publicKey := genericPublicKey.(*ecdsa.PublicKey)
 
-	return publicKey
+	return pem.EncodeToMemory(&pem.Block{Type: pemECDSAPrivate, Bytes: x509EncodedPriv})
 }
@@ -0,0 +1,175 @@
+// Package explorer serves HTML pages describing a chain: its tip and latest
+// blocks, blocks by height or hash, transactions, addresses and the mempool.
+//
+// Server is an http.Handler, so it can be mounted in an existing mux or run
+// on its own with ListenAndServe.
+package explorer
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"html/template"
+	"net"
+	"net/http"
+	"time"
+
+	"blockchain/pkg/blockchain"
+)
+
+// DefaultPageSize is the number of blocks or transactions listed per page
+const DefaultPageSize = 20
+
+//go:embed templates
+var templateFS embed.FS
+
+var pages = []string{"index", "block", "tx", "address", "mempool", "error"}
+
+// Mempool is the pool of unconfirmed transactions of a node, a *miner.TxPool
+type Mempool interface {
+	// All returns every transaction ready for a block
+	All() []*blockchain.Transaction
+	// Lookup returns the transaction with the id, or nil if the pool has none
+	Lookup(id []byte) *blockchain.Transaction
+}
+
+type Server struct {
+	chain   *blockchain.BlockChain
+	utxoSet *blockchain.UTXOSet
+	mempool Mempool
+
+	pageSize  int
+	templates map[string]*template.Template
+	mux       *http.ServeMux
+}
+
+type ServerOpt func(*Server)
+
+// WithMempool lists the transactions of pool on the mempool page and lets
+// the transaction pages find unconfirmed transactions
+func WithMempool(pool Mempool) ServerOpt {
+	return func(s *Server) {
+		s.mempool = pool
+	}
+}
+
+// WithPageSize sets the number of blocks or transactions listed per page
+func WithPageSize(size int) ServerOpt {
+	if size <= 0 {
+		size = DefaultPageSize
+	}
+	return func(s *Server) {
+		s.pageSize = size
+	}
+}
+
+// NewServer returns an explorer of chain. Address pages list transactions
+// only if chain was opened with blockchain.WithAddressIndex.
+func NewServer(chain *blockchain.BlockChain, opts ...ServerOpt) (*Server, error) {
+	s := &Server{
+		chain:    chain,
+		utxoSet:  blockchain.NewUTXOSet(chain),
+		pageSize: DefaultPageSize,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	var err error
+	if s.templates, err = parseTemplates(); err != nil {
+		return nil, err
+	}
+
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("/", s.handleIndex)
+	s.mux.HandleFunc("/block/", s.handleBlock)
+	s.mux.HandleFunc("/tx/", s.handleTx)
+	s.mux.HandleFunc("/address/", s.handleAddress)
+	s.mux.HandleFunc("/mempool", s.handleMempool)
+	s.mux.HandleFunc("/search", s.handleSearch)
+
+	return s, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe listens on addr and serves the explorer until ctx is done
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(ctx, ln)
+}
+
+// Serve serves the explorer on ln until ctx is done
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	srv := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	err := srv.Serve(ln)
+	if errors.Is(err, http.ErrServerClosed) {
+		return ctx.Err()
+	}
+
+	return err
+}
+
+func parseTemplates() (map[string]*template.Template, error) {
+	funcs := template.FuncMap{
+		"hex": hex.EncodeToString,
+		"time": func(unix int64) string {
+			return time.Unix(unix, 0).UTC().Format("2006-01-02 15:04:05 UTC")
+		},
+	}
+
+	layout, err := template.New("layout.html").Funcs(funcs).ParseFS(templateFS, "templates/layout.html")
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make(map[string]*template.Template, len(pages))
+	for _, page := range pages {
+		t, err := template.Must(layout.Clone()).ParseFS(templateFS, "templates/"+page+".html")
+		if err != nil {
+			return nil, err
+		}
+		templates[page] = t
+	}
+
+	return templates, nil
+}
+
+// render writes page, or an error page if it can't be rendered
+func (s *Server) render(w http.ResponseWriter, status int, page string, data interface{}) {
+	var buf bytes.Buffer
+	if err := s.templates[page].ExecuteTemplate(&buf, "layout", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = buf.WriteTo(w)
+}
+
+func (s *Server) renderError(w http.ResponseWriter, status int, err error) {
+	s.render(w, status, "error", struct {
+		Status  int
+		Message string
+	}{status, err.Error()})
+}
@@ -0,0 +1,323 @@
+package explorer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"blockchain/pkg/blockchain"
+	"blockchain/pkg/consensus"
+	"blockchain/pkg/wallet"
+)
+
+var errNotFound = errors.New("not found")
+
+type blockSummary struct {
+	Height       int
+	Hash         []byte
+	Timestamp    int64
+	Transactions int
+	Weight       int
+}
+
+type indexPage struct {
+	Network string
+	Tip     *consensus.Header
+	Blocks  []blockSummary
+	// Older is the height the next page starts at, -1 on the last page
+	Older int
+	// Mempool is the number of unconfirmed transactions, -1 without a mempool
+	Mempool int
+}
+
+// handleIndex shows the chain tip and the latest blocks, older ones with ?before=height
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		s.renderError(w, http.StatusNotFound, fmt.Errorf("%w: %s", errNotFound, r.URL.Path))
+		return
+	}
+
+	tip, err := s.chain.GetHeader(s.chain.LastHash())
+	if err != nil {
+		s.renderError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	before := tip.Height
+	if q := r.URL.Query().Get("before"); q != "" {
+		if before, err = strconv.Atoi(q); err != nil || before < 0 {
+			s.renderError(w, http.StatusBadRequest, fmt.Errorf("invalid height %q", q))
+			return
+		}
+	}
+
+	page := indexPage{
+		Network: s.chain.Params().Name,
+		Tip:     tip,
+		Older:   -1,
+		Mempool: -1,
+	}
+	if s.mempool != nil {
+		page.Mempool = len(s.mempool.All())
+	}
+
+	iter := s.chain.Iterator(blockchain.WithHeights(-1, before))
+	for len(page.Blocks) < s.pageSize && iter.Next() {
+		block := iter.Block()
+		page.Blocks = append(page.Blocks, blockSummary{
+			Height:       block.Height,
+			Hash:         block.Hash,
+			Timestamp:    block.Timestamp,
+			Transactions: len(block.Transactions),
+			Weight:       block.Weight(),
+		})
+	}
+	if err := iter.Err(); err != nil {
+		s.renderError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if n := len(page.Blocks); n == s.pageSize && page.Blocks[n-1].Height > 0 {
+		page.Older = page.Blocks[n-1].Height - 1
+	}
+
+	s.render(w, http.StatusOK, "index", page)
+}
+
+type blockPage struct {
+	Block *blockchain.Block
+	// MainChain is false for a block disconnected by a reorganization
+	MainChain     bool
+	Confirmations int
+	Next          []byte
+	SealValid     bool
+	Weight        int
+	SigOps        int
+}
+
+// handleBlock shows the block with the height or hash at the end of the path
+func (s *Server) handleBlock(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/block/")
+
+	block, err := s.lookupBlock(id)
+	if err != nil {
+		s.renderError(w, statusOf(err), err)
+		return
+	}
+
+	page := blockPage{
+		Block:     block,
+		SealValid: s.chain.Engine().VerifySeal(s.chain, &block.Header) == nil,
+		Weight:    block.Weight(),
+		SigOps:    block.SigOps(),
+	}
+	if hash, err := s.chain.BlockHash(block.Height); err == nil && bytes.Equal(hash, block.Hash) {
+		page.MainChain = true
+		if tip, err := s.chain.GetHeader(s.chain.LastHash()); err == nil {
+			page.Confirmations = tip.Height - block.Height + 1
+		}
+		page.Next, _ = s.chain.BlockHash(block.Height + 1)
+	}
+
+	s.render(w, http.StatusOK, "block", page)
+}
+
+func (s *Server) lookupBlock(id string) (*blockchain.Block, error) {
+	if height, err := strconv.Atoi(id); err == nil {
+		hash, err := s.chain.BlockHash(height)
+		if err != nil {
+			return nil, err
+		}
+		return s.chain.GetBlock(hash)
+	}
+
+	hash, err := hex.DecodeString(id)
+	if err != nil || len(hash) == 0 {
+		return nil, fmt.Errorf("%w: block %q", errNotFound, id)
+	}
+
+	return s.chain.GetBlock(hash)
+}
+
+type txPage struct {
+	Tx *blockchain.Transaction
+	// Block is nil for a transaction of the mempool
+	Block         *blockchain.Block
+	Confirmations int
+	Weight        int
+}
+
+// handleTx shows the transaction with the id at the end of the path
+func (s *Server) handleTx(w http.ResponseWriter, r *http.Request) {
+	id, err := hex.DecodeString(strings.TrimPrefix(r.URL.Path, "/tx/"))
+	if err != nil || len(id) == 0 {
+		s.renderError(w, http.StatusNotFound, fmt.Errorf("%w: transaction %q", errNotFound, r.URL.Path))
+		return
+	}
+
+	tx, block, err := s.findTx(id)
+	if err != nil {
+		s.renderError(w, statusOf(err), err)
+		return
+	}
+
+	page := txPage{Tx: tx, Block: block, Weight: tx.Weight()}
+	if block != nil {
+		if tip, err := s.chain.GetHeader(s.chain.LastHash()); err == nil {
+			page.Confirmations = tip.Height - block.Height + 1
+		}
+	}
+
+	s.render(w, http.StatusOK, "tx", page)
+}
+
+// findTx returns the transaction with the given id and the main chain block
+// holding it, or no block if it is in the mempool
+func (s *Server) findTx(id []byte) (*blockchain.Transaction, *blockchain.Block, error) {
+	tx, block, err := s.chain.FindTransactionBlock(id)
+	if !errors.Is(err, blockchain.ErrorTxNotFound) {
+		return tx, block, err
+	}
+
+	if s.mempool != nil {
+		if tx := s.mempool.Lookup(id); tx != nil {
+			return tx, nil, nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("%w: %x", blockchain.ErrorTxNotFound, id)
+}
+
+type addressPage struct {
+	Address    string
+	PubKeyHash []byte
+	Balance    int
+	UTXOs      int
+
+	// IndexDisabled is set when the chain keeps no address index
+	IndexDisabled bool
+	History       []blockchain.AddressTx
+	Total         int
+	// Prev and Next are the neighbouring page numbers, -1 if there is none
+	Page, Prev, Next int
+}
+
+// handleAddress shows the balance and the transactions of the address or
+// hex public key hash at the end of the path, page by page with ?page=n
+func (s *Server) handleAddress(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/address/")
+
+	pubKeyHash, err := wallet.PubKeyHashFromAddress(id)
+	if err != nil {
+		// outputs only carry the hash, the address version can't be recovered
+		if pubKeyHash, err = hex.DecodeString(id); err != nil || len(pubKeyHash) == 0 {
+			s.renderError(w, http.StatusNotFound, fmt.Errorf("%w: address %q", errNotFound, id))
+			return
+		}
+	}
+
+	page := addressPage{Address: id, PubKeyHash: pubKeyHash, Prev: -1, Next: -1}
+	if q := r.URL.Query().Get("page"); q != "" {
+		if page.Page, err = strconv.Atoi(q); err != nil || page.Page < 0 {
+			s.renderError(w, http.StatusBadRequest, fmt.Errorf("invalid page %q", q))
+			return
+		}
+	}
+
+	outs, err := s.utxoSet.FindUTXOs(pubKeyHash)
+	if err != nil {
+		s.renderError(w, http.StatusInternalServerError, err)
+		return
+	}
+	for _, out := range *outs {
+		page.Balance += out.Value
+		page.UTXOs++
+	}
+
+	page.History, page.Total, err = s.chain.History(pubKeyHash, page.Page*s.pageSize, s.pageSize)
+	switch {
+	case errors.Is(err, blockchain.ErrorAddrIndexDisabled):
+		page.IndexDisabled = true
+	case err != nil:
+		s.renderError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if page.Page > 0 {
+		page.Prev = page.Page - 1
+	}
+	if (page.Page+1)*s.pageSize < page.Total {
+		page.Next = page.Page + 1
+	}
+
+	s.render(w, http.StatusOK, "address", page)
+}
+
+type mempoolTx struct {
+	ID      []byte
+	Inputs  int
+	Outputs int
+	Value   int
+	Weight  int
+}
+
+type mempoolPage struct {
+	Attached     bool
+	Transactions []mempoolTx
+}
+
+// handleMempool lists the unconfirmed transactions ready for a block
+func (s *Server) handleMempool(w http.ResponseWriter, r *http.Request) {
+	page := mempoolPage{Attached: s.mempool != nil}
+	if s.mempool != nil {
+		for _, tx := range s.mempool.All() {
+			value := 0
+			for _, out := range tx.Outputs {
+				value += out.Value
+			}
+			page.Transactions = append(page.Transactions, mempoolTx{
+				ID:      tx.ID,
+				Inputs:  len(tx.Inputs),
+				Outputs: len(tx.Outputs),
+				Value:   value,
+				Weight:  tx.Weight(),
+			})
+		}
+	}
+
+	s.render(w, http.StatusOK, "mempool", page)
+}
+
+// handleSearch redirects ?q= to the page of the block height, block hash,
+// transaction id or address it holds
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+
+	var target string
+	if _, err := strconv.Atoi(q); err == nil {
+		target = "/block/" + q
+	} else if hash, err := hex.DecodeString(q); err == nil && len(hash) == 32 {
+		target = "/tx/" + q
+		if _, err := s.chain.GetBlock(hash); err == nil {
+			target = "/block/" + q
+		}
+	} else if _, err := wallet.PubKeyHashFromAddress(q); err == nil {
+		target = "/address/" + q
+	} else {
+		s.renderError(w, http.StatusNotFound, fmt.Errorf("%w: nothing matches %q", errNotFound, q))
+		return
+	}
+
+	http.Redirect(w, r, (&url.URL{Path: target}).String(), http.StatusFound)
+}
+
+func statusOf(err error) int {
+	if errors.Is(err, errNotFound) || errors.Is(err, blockchain.ErrorBlkNotFound) || errors.Is(err, blockchain.ErrorTxNotFound) {
+		return http.StatusNotFound
+	}
+
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,253 @@
+package explorer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"blockchain/pkg/blockchain"
+	"blockchain/pkg/crypto"
+	"blockchain/pkg/wallet"
+)
+
+// testMempool holds transactions ready for a block and ones waiting for
+// their lock time, like a *miner.TxPool
+type testMempool struct {
+	txs, nonFinal []*blockchain.Transaction
+}
+
+func (p *testMempool) All() []*blockchain.Transaction {
+	return p.txs
+}
+
+func (p *testMempool) Lookup(id []byte) *blockchain.Transaction {
+	for _, tx := range append(p.txs, p.nonFinal...) {
+		if bytes.Equal(tx.ID, id) {
+			return tx
+		}
+	}
+
+	return nil
+}
+
+// testChain is a chain whose genesis pays alice, and whose block at
+// height 1 holds tx, paying 5 to bob
+type testChain struct {
+	*blockchain.BlockChain
+	alice, bob string
+	block      *blockchain.Block
+	tx         *blockchain.Transaction
+}
+
+func newTestWallet(t *testing.T) string {
+	t.Helper()
+
+	address, err := wallet.CreateWallet(crypto.KeyECDSA)
+	if err != nil {
+		t.Fatalf("CreateWallet: %v", err)
+	}
+
+	return address
+}
+
+func newTestChain(t *testing.T, opts ...blockchain.BlockChainOpt) *testChain {
+	t.Helper()
+
+	c := &testChain{alice: newTestWallet(t), bob: newTestWallet(t)}
+
+	var err error
+	opts = append([]blockchain.BlockChainOpt{blockchain.WithDataDir(t.TempDir())}, opts...)
+	if c.BlockChain, err = blockchain.InitBlockChain(c.alice, opts...); err != nil {
+		t.Fatalf("InitBlockChain: %v", err)
+	}
+	t.Cleanup(c.Close)
+
+	if c.tx, err = blockchain.NewTransaction(c.alice, c.bob, 5, blockchain.NewUTXOSet(c.BlockChain)); err != nil {
+		t.Fatalf("NewTransaction: %v", err)
+	}
+	cbTx, err := blockchain.CoinbaseTx(c.alice, "")
+	if err != nil {
+		t.Fatalf("CoinbaseTx: %v", err)
+	}
+	if c.block, err = c.MineBlock([]*blockchain.Transaction{c.tx, cbTx}); err != nil {
+		t.Fatalf("MineBlock: %v", err)
+	}
+
+	return c
+}
+
+// newTestTx returns a transaction of alice which is not in the chain
+func (c *testChain) newTestTx(t *testing.T) *blockchain.Transaction {
+	t.Helper()
+
+	tx, err := blockchain.NewTransaction(c.alice, c.bob, 1, blockchain.NewUTXOSet(c.BlockChain))
+	if err != nil {
+		t.Fatalf("NewTransaction: %v", err)
+	}
+
+	return tx
+}
+
+func newTestServer(t *testing.T, chain *blockchain.BlockChain, opts ...ServerOpt) *Server {
+	t.Helper()
+
+	s, err := NewServer(chain, opts...)
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+
+	return s
+}
+
+// get requests path from s and checks the status and that the body holds want
+func get(t *testing.T, s *Server, path string, status int, want ...string) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	if w.Code != status {
+		t.Errorf("GET %s: got status %d, want %d", path, w.Code, status)
+	}
+	for _, s := range want {
+		if !strings.Contains(w.Body.String(), s) {
+			t.Errorf("GET %s: body does not hold %q", path, s)
+		}
+	}
+}
+
+func TestIndexPage(t *testing.T) {
+	chain := newTestChain(t)
+	blockHash := hex.EncodeToString(chain.block.Hash)
+
+	s := newTestServer(t, chain.BlockChain)
+	get(t, s, "/", http.StatusOK, "<h1>devnet</h1>", blockHash, `<a href="/block/0">0</a>`)
+	get(t, s, "/?before=0", http.StatusOK, `<a href="/block/0">0</a>`)
+	get(t, s, "/?before=-1", http.StatusBadRequest)
+	get(t, s, "/nothing/here", http.StatusNotFound)
+
+	pool := &testMempool{}
+	for i := 0; i < 3; i++ {
+		pool.txs = append(pool.txs, chain.newTestTx(t))
+	}
+	s = newTestServer(t, chain.BlockChain, WithMempool(pool))
+	get(t, s, "/", http.StatusOK, `<a href="/mempool">3 transactions</a>`)
+}
+
+func TestBlockPage(t *testing.T) {
+	chain := newTestChain(t)
+	blockHash := hex.EncodeToString(chain.block.Hash)
+	s := newTestServer(t, chain.BlockChain)
+
+	for _, id := range []string{"1", blockHash} {
+		get(t, s, "/block/"+id, http.StatusOK,
+			"<h1>Block 1</h1>",
+			blockHash,
+			"<tr><th>Confirmations</th><td>1</td></tr>",
+			"<tr><th>Seal</th><td>valid</td></tr>",
+			`<a href="/tx/`+hex.EncodeToString(chain.tx.ID)+`">`,
+		)
+	}
+	get(t, s, "/block/0", http.StatusOK, "none, genesis block", `<a href="/block/`+blockHash+`">`)
+
+	for _, id := range []string{"2", "-1", strings.Repeat("ff", 32), "not-a-block"} {
+		get(t, s, "/block/"+id, http.StatusNotFound, "<h1>Error 404</h1>")
+	}
+}
+
+func TestTxPage(t *testing.T) {
+	chain := newTestChain(t)
+	pending, waiting := chain.newTestTx(t), chain.newTestTx(t)
+	pool := &testMempool{
+		txs:      []*blockchain.Transaction{pending},
+		nonFinal: []*blockchain.Transaction{waiting},
+	}
+	s := newTestServer(t, chain.BlockChain, WithMempool(pool))
+
+	get(t, s, "/tx/"+hex.EncodeToString(chain.tx.ID), http.StatusOK,
+		`<a href="/block/`+hex.EncodeToString(chain.block.Hash)+`">1</a>`,
+		"<tr><th>Confirmations</th><td>1</td></tr>",
+		"<td>5</td>",
+	)
+	get(t, s, "/tx/"+hex.EncodeToString(chain.block.Transactions[1].ID), http.StatusOK, "Coinbase, the block reward.")
+
+	// unconfirmed transactions, one waiting for its lock time too
+	for _, tx := range []*blockchain.Transaction{pending, waiting} {
+		get(t, s, "/tx/"+hex.EncodeToString(tx.ID), http.StatusOK, "unconfirmed, in the mempool")
+	}
+	get(t, s, "/mempool", http.StatusOK, hex.EncodeToString(pending.ID))
+
+	for _, id := range []string{strings.Repeat("ff", 32), "not-a-tx", ""} {
+		get(t, s, "/tx/"+id, http.StatusNotFound, "<h1>Error 404</h1>")
+	}
+
+	// without a mempool only the chain is searched
+	s = newTestServer(t, chain.BlockChain)
+	get(t, s, "/tx/"+hex.EncodeToString(pending.ID), http.StatusNotFound)
+	get(t, s, "/mempool", http.StatusOK, "no mempool attached")
+}
+
+func TestAddressPage(t *testing.T) {
+	for _, indexed := range []bool{false, true} {
+		t.Run(fmt.Sprintf("indexed=%v", indexed), func(t *testing.T) {
+			var opts []blockchain.BlockChainOpt
+			if indexed {
+				opts = append(opts, blockchain.WithAddressIndex())
+			}
+			chain := newTestChain(t, opts...)
+			s := newTestServer(t, chain.BlockChain)
+
+			pubKeyHash, err := wallet.PubKeyHashFromAddress(chain.bob)
+			if err != nil {
+				t.Fatalf("PubKeyHashFromAddress: %v", err)
+			}
+			want := []string{
+				"<tr><th>Balance</th><td>5</td></tr>",
+				"<tr><th>Unspent outputs</th><td>1</td></tr>",
+			}
+			if indexed {
+				want = append(want, "<p>1 transactions</p>", hex.EncodeToString(chain.tx.ID))
+			} else {
+				want = append(want, "The address index is disabled")
+			}
+
+			// outputs link to the public key hash, it is looked up like the address
+			for _, id := range []string{chain.bob, hex.EncodeToString(pubKeyHash)} {
+				get(t, s, "/address/"+id, http.StatusOK, want...)
+			}
+
+			get(t, s, "/address/"+chain.bob+"?page=-1", http.StatusBadRequest)
+			get(t, s, "/address/not-an-address", http.StatusNotFound, "<h1>Error 404</h1>")
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	chain := newTestChain(t)
+	s := newTestServer(t, chain.BlockChain)
+	blockHash, txID := hex.EncodeToString(chain.block.Hash), hex.EncodeToString(chain.tx.ID)
+
+	for q, target := range map[string]string{
+		"1":        "/block/1",
+		blockHash:  "/block/" + blockHash,
+		txID:       "/tx/" + txID,
+		chain.bob:  "/address/" + chain.bob,
+		" 1 ":      "/block/1",
+		"nothing!": "",
+	} {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/search?q="+strings.ReplaceAll(q, " ", "+"), nil))
+		if target == "" {
+			if w.Code != http.StatusNotFound {
+				t.Errorf("search %q: got status %d, want %d", q, w.Code, http.StatusNotFound)
+			}
+			continue
+		}
+		if loc := w.Header().Get("Location"); w.Code != http.StatusFound || loc != target {
+			t.Errorf("search %q: got status %d to %q, want a redirect to %q", q, w.Code, loc, target)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package explorer
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+// TestMain runs the tests in a scratch directory, wallets are stored
+// relative to the working directory
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "explorer-test")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	code := func() int {
+		defer os.RemoveAll(dir)
+
+		if err := os.Chdir(dir); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if err := os.MkdirAll("tmp/wallets", 0755); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		return m.Run()
+	}()
+	os.Exit(code)
+}
@@ -2,11 +2,21 @@ package miner
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
+	"strconv"
+	"time"
 
 	"blockchain/pkg/blockchain"
 	"blockchain/pkg/p2p"
 )
 
+// timeSampler is a time source following the clocks of peers, like the
+// blockchain.MedianTime of a chain
+type timeSampler interface {
+	AddTimeSample(peer string, peerTime time.Time)
+}
+
 func HandlerTx(m *Miner) p2p.Handler {
 	return func(data []byte, rw *bufio.ReadWriter) {
 		tx := m.rawTxPool.Get().(*blockchain.Transaction)
@@ -21,5 +31,54 @@ func HandlerTx(m *Miner) p2p.Handler {
 		}
 
 		m.pool.Add(tx)
+		m.chain.NotifyTxAccepted(tx)
 	}
 }
+
+// HandlerBlock adds a block mined by another node, followChain then
+// abandons the block being mined, which no longer extends the tip
+func HandlerBlock(m *Miner) p2p.Handler {
+	return func(data []byte, rw *bufio.ReadWriter) {
+		block := &blockchain.Block{}
+		if err := block.Deserialize(data); err != nil {
+			return
+		}
+
+		_ = m.chain.AddBlock(block)
+	}
+}
+
+// HandlerHeartbeat records the clock of the peer sending a heartbeat, the
+// adjusted time block timestamps are checked against follows the median
+// of the peers
+func HandlerHeartbeat(m *Miner) p2p.Handler {
+	return func(data []byte, rw *bufio.ReadWriter) {
+		peerAddr, peerTime, err := parseHeartbeat(data)
+		if err != nil || peerAddr == m.nodeAddr {
+			return
+		}
+
+		if ts, ok := m.chain.TimeSource().(timeSampler); ok {
+			ts.AddTimeSample(peerAddr, peerTime)
+		}
+	}
+}
+
+// formatHeartbeat returns the address of the node followed by its unix time
+func formatHeartbeat(nodeAddr string, now time.Time) []byte {
+	return []byte(nodeAddr + " " + strconv.FormatInt(now.Unix(), 10))
+}
+
+func parseHeartbeat(data []byte) (string, time.Time, error) {
+	i := bytes.LastIndexByte(data, ' ')
+	if i < 0 {
+		return "", time.Time{}, errors.New("heartbeat without time")
+	}
+
+	unix, err := strconv.ParseInt(string(data[i+1:]), 10, 64)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return string(data[:i]), time.Unix(unix, 0), nil
+}
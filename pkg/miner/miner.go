@@ -2,8 +2,9 @@ package miner
 
 import (
 	"bufio"
+	"bytes"
 	"context"
-	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"os"
 	"runtime"
@@ -15,6 +16,8 @@ import (
 	"github.com/vrecan/death/v3"
 
 	"blockchain/pkg/blockchain"
+	"blockchain/pkg/consensus"
+	"blockchain/pkg/crypto"
 	"blockchain/pkg/p2p"
 	"blockchain/pkg/wallet"
 )
@@ -32,14 +35,22 @@ type Miner struct {
 	peers        map[string]*bufio.ReadWriter // peers[addr] = rw
 	host         host.Host
 
-	privateKey *ecdsa.PrivateKey
-	publicKey  *ecdsa.PublicKey
+	privateKey crypto.Signer
+	publicKey  crypto.Verifier
 
 	pool      *TxPool
 	rawTxPool sync.Pool
 
 	ctx    context.Context
 	cancel context.CancelFunc
+
+	// roundCancel stops sealing the current block, a new tip makes it stale
+	roundMu     sync.Mutex
+	roundCancel context.CancelFunc
+	roundParent []byte
+
+	// chainEvents keeps the pool in step with the chain
+	chainEvents *blockchain.Subscription
 }
 
 func init() {
@@ -57,11 +68,25 @@ func NewMiner(walletAddr, fullNodeAddr string, port int, chain *blockchain.Block
 	if err != nil {
 		panic(err)
 	}
-	_host.SetStreamHandler("/miner/1.0.0", p2p.MakeStreamHandler(handlers))
+	height, err := chain.GetBaseHeight()
+	if err != nil {
+		panic(err)
+	}
+	params := chain.Params()
+	pool := NewTxPool(
+		WithPackSize(DefaultPackSize),
+		WithPackTick(DefaultPackTickSec*time.Second),
+		WithBlockLimits(params.MaxBlockWeight-CoinbaseReserveWeight, params.MaxBlockSigOps),
+	)
+	mtp, err := chain.MedianTimePast(chain.LastHash())
+	if err != nil {
+		panic(err)
+	}
+	pool.SetTip(height, mtp)
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &Miner{
+	m := &Miner{
 		walletAddr:   walletAddr,
 		chain:        chain,
 		utxoSet:      blockchain.NewUTXOSet(chain),
@@ -70,7 +95,7 @@ func NewMiner(walletAddr, fullNodeAddr string, port int, chain *blockchain.Block
 		nodeAddr:     nodeAddr,
 		privateKey:   wa.PrivateKey,
 		publicKey:    wa.PublicKey,
-		pool:         NewTxPool(),
+		pool:         pool,
 		rawTxPool: sync.Pool{
 			New: func() interface{} {
 				return &blockchain.Transaction{}
@@ -78,7 +103,54 @@ func NewMiner(walletAddr, fullNodeAddr string, port int, chain *blockchain.Block
 		},
 		ctx:    ctx,
 		cancel: cancel,
+		chainEvents: chain.Subscribe(
+			blockchain.WithEventTypes(blockchain.EventBlockConnected),
+			blockchain.WithBackpressure(blockchain.BackpressureBlock),
+		),
+	}
+
+	handlers["tx"] = HandlerTx(m)
+	handlers["block"] = HandlerBlock(m)
+	handlers["heartbeat"] = HandlerHeartbeat(m)
+	_host.SetStreamHandler("/miner/1.0.0", p2p.MakeStreamHandler(handlers))
+
+	return m
+}
+
+// Pool returns the unconfirmed transactions waiting for a block, to show
+// them in an explorer.Server for instance
+func (m *Miner) Pool() *TxPool {
+	return m.pool
+}
+
+// SubmitTransaction adds a transaction received out of band, from an
+// rpc.Server for instance, to the pool and relays it to the peers
+func (m *Miner) SubmitTransaction(tx *blockchain.Transaction) error {
+	if !m.chain.VerifyTransaction(tx) {
+		return fmt.Errorf("%w: %x", blockchain.ErrorTxInvalid, tx.ID)
+	}
+
+	txData, err := tx.Serialize()
+	if err != nil {
+		return err
+	}
+
+	m.pool.Add(tx)
+	m.chain.NotifyTxAccepted(tx)
+	m.broadcastCommand("tx", txData)
+
+	return nil
+}
+
+// Peers returns the multiaddress of each connected peer
+func (m *Miner) Peers() []string {
+	conns := m.host.Network().Conns()
+	peers := make([]string, 0, len(conns))
+	for _, conn := range conns {
+		peers = append(peers, fmt.Sprintf("%s/p2p/%s", conn.RemoteMultiaddr(), conn.RemotePeer()))
 	}
+
+	return peers
 }
 
 func (m *Miner) Start() {
@@ -90,6 +162,8 @@ func (m *Miner) Start() {
 
 	go m.gracefulShutdown()
 
+	go m.followChain()
+
 	go m.mine()
 
 	go m.heartbeat(rw)
@@ -115,21 +189,134 @@ func (m *Miner) mine() {
 			}
 			txs = append(txs, cbTx)
 
-			block, err := m.chain.MineBlock(txs)
+			parent := m.chain.LastHash()
+			block, err := m.mineRound(txs)
+			if errors.Is(err, consensus.ErrorNotInTurn) {
+				// another signer seals the next block, try again on top of it
+				m.requeue(txs[:len(txs)-1])
+				m.waitForBlock(parent)
+				continue
+			}
+			if err != nil && m.ctx.Err() == nil && retryable(err) {
+				// another block arrived first or made some transactions
+				// invalid, mine what is left on top of the new tip
+				m.requeue(txs[:len(txs)-1])
+				continue
+			}
 			if err != nil {
 				m.cancel()
 				return
 			}
 
-			_ = m.utxoSet.Reindex()
 			blkData, _ := block.Serialize()
 			m.broadcastCommand("block", blkData)
+		}
+	}
+}
+
+// waitForBlock returns once the chain tip moved on from parent, or the
+// miner stops
+func (m *Miner) waitForBlock(parent []byte) {
+	sub := m.chain.Subscribe(
+		blockchain.WithEventTypes(blockchain.EventBlockConnected),
+		blockchain.WithBufferSize(1),
+		blockchain.WithBackpressure(blockchain.BackpressureDrop),
+	)
+	defer sub.Unsubscribe()
+
+	// the block may have arrived before the subscription
+	if !bytes.Equal(parent, m.chain.LastHash()) {
+		return
+	}
+
+	select {
+	case <-m.ctx.Done():
+	case <-sub.Events():
+	}
+}
 
-			m.putBackTxs(txs[:len(txs)-1])
+// retryable reports whether a round failed because of a stale tip or of
+// its transactions rather than of the storage
+func retryable(err error) bool {
+	for _, target := range []error{
+		context.Canceled,
+		blockchain.ErrorBlkHeightInvalid,
+		blockchain.ErrorBlkPrevHashInvalid,
+		blockchain.ErrorTxInvalid,
+		blockchain.ErrorTxNotFinal,
+		blockchain.ErrorTxDoubleSpend,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// requeue puts the transactions of a failed round back in the pool, those
+// confirmed or invalidated in the meantime are dropped
+func (m *Miner) requeue(txs []*blockchain.Transaction) {
+	for _, tx := range txs {
+		if m.chain.VerifyTransaction(tx) {
+			m.pool.Add(tx)
 		}
 	}
 }
 
+// followChain updates the pool as blocks are connected, whoever added them
+func (m *Miner) followChain() {
+	defer m.chainEvents.Unsubscribe()
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case ev, ok := <-m.chainEvents.Events():
+			if !ok {
+				return
+			}
+
+			m.abortStaleRound()
+			m.pool.Remove(ev.Block.Transactions)
+			m.setTip(&ev.Block.Header)
+		}
+	}
+}
+
+// setTip updates the pool once header is the chain tip
+func (m *Miner) setTip(header *consensus.Header) {
+	mtp, err := m.chain.MedianTimePast(header.Hash)
+	if err != nil {
+		return
+	}
+	m.pool.SetTip(header.Height, mtp)
+}
+
+func (m *Miner) mineRound(txs []*blockchain.Transaction) (*blockchain.Block, error) {
+	ctx, cancel := context.WithCancel(m.ctx)
+	defer cancel()
+
+	m.roundMu.Lock()
+	m.roundCancel = cancel
+	m.roundParent = m.chain.LastHash()
+	m.roundMu.Unlock()
+
+	return m.chain.MineBlockContext(ctx, txs)
+}
+
+// abortStaleRound stops sealing the block being mined, if any, unless it
+// already builds on the chain tip
+func (m *Miner) abortStaleRound() {
+	m.roundMu.Lock()
+	defer m.roundMu.Unlock()
+
+	if m.roundCancel != nil && !bytes.Equal(m.roundParent, m.chain.LastHash()) {
+		m.roundCancel()
+		m.roundCancel = nil
+	}
+}
+
 func (m *Miner) gracefulShutdown() {
 	d := death.NewDeath(syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
 	d.WaitForDeathWithFunc(func() {
@@ -154,22 +341,25 @@ func (m *Miner) shutdown() {
 	m.cancel()
 }
 
+// heartbeat tells the peer the node is alive and what its clock says, the
+// first one goes out as soon as the node is connected
 func (m *Miner) heartbeat(rw *bufio.ReadWriter) {
 	var count int
 	for {
+		if err := p2p.SendCommand(rw, "heartbeat", formatHeartbeat(m.nodeAddr, time.Now())); err != nil {
+			count++
+			if count > 30 {
+				m.cancel()
+				return
+			}
+		} else {
+			count = 0
+		}
+
 		select {
 		case <-m.ctx.Done():
 			return
 		case <-time.After(5 * time.Second):
-			if err := p2p.SendCommand(rw, "heartbeat", []byte(m.nodeAddr)); err != nil {
-				count++
-				if count > 30 {
-					m.cancel()
-					return
-				}
-			} else {
-				count = 0
-			}
 		}
 	}
 }
@@ -181,9 +371,3 @@ func (m *Miner) broadcastCommand(cmd string, data []byte) {
 		}
 	}
 }
-
-func (m *Miner) putBackTxs(txs []*blockchain.Transaction) {
-	for _, tx := range txs {
-		m.rawTxPool.Put(tx)
-	}
-}
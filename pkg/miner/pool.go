@@ -1,6 +1,7 @@
 package miner
 
 import (
+	"bytes"
 	"context"
 	"sync"
 	"time"
@@ -13,17 +14,29 @@ const (
 	DefaultPackSize = 10
 	// DefaultPackTickSec is the default number of seconds to wait before packing transactions
 	DefaultPackTickSec = 30
+	// CoinbaseReserveWeight is the block weight left for the coinbase when packing transactions
+	CoinbaseReserveWeight = 4000
 )
 
 // TxPool is a pool of unconfirmed transactions
 type TxPool struct {
 	unconfirmedTxs []*blockchain.Transaction
+	// nonFinalTxs are held back until their lock time has passed
+	nonFinalTxs []*blockchain.Transaction
+	// height is the height of the current chain tip
+	height int
+	// medianTime is the median time past of the chain tip, lock times are checked against it
+	medianTime int64
 
 	mu sync.Mutex
 
 	packSize int
 	packTick time.Duration
 
+	// maxWeight and maxSigOps bound the transactions of a pack, 0 for no bound
+	maxWeight int
+	maxSigOps int
+
 	packSignal chan struct{}
 }
 
@@ -58,26 +71,142 @@ func WithPackTick(tick time.Duration) TxPoolOpt {
 	}
 }
 
+// WithBlockLimits bounds the weight and the signature operations of the
+// transactions packed into a block, those left over wait for the next one
+func WithBlockLimits(maxWeight, maxSigOps int) TxPoolOpt {
+	return func(p *TxPool) {
+		p.maxWeight = maxWeight
+		p.maxSigOps = maxSigOps
+	}
+}
+
 func (p *TxPool) Add(tx *blockchain.Transaction) {
 	p.mu.Lock()
+	if !tx.IsFinal(p.height+1, p.medianTime) {
+		p.nonFinalTxs = append(p.nonFinalTxs, tx)
+		p.mu.Unlock()
+		return
+	}
 	p.unconfirmedTxs = append(p.unconfirmedTxs, tx)
 	p.mu.Unlock()
 
+	// GetPack checks the pool on its tick anyway, no need to wait for it
 	if len(p.unconfirmedTxs) >= p.packSize {
-		p.packSignal <- struct{}{}
+		select {
+		case p.packSignal <- struct{}{}:
+		default:
+		}
 	}
 }
 
+// SetTip updates the height and the median time past of the chain tip and
+// releases the held back transactions which can be included in the next block
+func (p *TxPool) SetTip(height int, medianTime int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.height = height
+	p.medianTime = medianTime
+	p.promoteFinal()
+}
+
+// NonFinalCount returns the number of transactions waiting for their lock time
+func (p *TxPool) NonFinalCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.nonFinalTxs)
+}
+
+// Pending returns up to max transactions ready for the next block, leaving them in the pool
+func (p *TxPool) Pending(max int) []*blockchain.Transaction {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.promoteFinal()
+	if max <= 0 || max > len(p.unconfirmedTxs) {
+		max = len(p.unconfirmedTxs)
+	}
+
+	txs, _ := p.fit(p.unconfirmedTxs[:max])
+
+	return txs
+}
+
+// All returns every transaction ready for a block, however many blocks they
+// fill, leaving them in the pool
+func (p *TxPool) All() []*blockchain.Transaction {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.promoteFinal()
+
+	return append([]*blockchain.Transaction(nil), p.unconfirmedTxs...)
+}
+
+// Lookup returns the transaction of the pool with the id, one waiting for
+// its lock time included, or nil
+func (p *TxPool) Lookup(id []byte) *blockchain.Transaction {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, txs := range [][]*blockchain.Transaction{p.unconfirmedTxs, p.nonFinalTxs} {
+		for _, tx := range txs {
+			if bytes.Equal(tx.ID, id) {
+				return tx
+			}
+		}
+	}
+
+	return nil
+}
+
+// Remove drops the transactions confirmed by a block from the pool
+func (p *TxPool) Remove(txs []*blockchain.Transaction) {
+	confirmed := make(map[string]struct{}, len(txs))
+	for _, tx := range txs {
+		confirmed[string(tx.ID)] = struct{}{}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	keep := p.unconfirmedTxs[:0]
+	for _, tx := range p.unconfirmedTxs {
+		if _, ok := confirmed[string(tx.ID)]; !ok {
+			keep = append(keep, tx)
+		}
+	}
+	p.unconfirmedTxs = keep
+}
+
+func (p *TxPool) promoteFinal() {
+	pending := p.nonFinalTxs[:0]
+	for _, tx := range p.nonFinalTxs {
+		if tx.IsFinal(p.height+1, p.medianTime) {
+			p.unconfirmedTxs = append(p.unconfirmedTxs, tx)
+			continue
+		}
+		pending = append(pending, tx)
+	}
+	p.nonFinalTxs = pending
+}
+
 func (p *TxPool) GetPack() []*blockchain.Transaction {
 	ctx, cancel := context.WithTimeout(context.Background(), p.packTick)
 	defer cancel()
 
+	p.mu.Lock()
+	p.promoteFinal()
+	p.mu.Unlock()
+
 	for {
 		select {
 		case <-ctx.Done():
 			p.mu.Lock()
-			txs := p.unconfirmedTxs
-			p.unconfirmedTxs = nil
+			txs, rest := p.fit(p.unconfirmedTxs)
+			p.unconfirmedTxs = rest
+			p.mu.Unlock()
 			return txs
 		case <-p.packSignal:
 			p.mu.Lock()
@@ -85,10 +214,40 @@ func (p *TxPool) GetPack() []*blockchain.Transaction {
 				p.mu.Unlock()
 				continue
 			}
-			txs := p.unconfirmedTxs[:p.packSize]
-			p.unconfirmedTxs = p.unconfirmedTxs[p.packSize:]
+			txs, rest := p.fit(p.unconfirmedTxs[:p.packSize])
+			p.unconfirmedTxs = append(rest, p.unconfirmedTxs[p.packSize:]...)
 			p.mu.Unlock()
 			return txs
 		}
 	}
 }
+
+// fit returns the transactions of txs which fit in a block, in order, and
+// the ones left for a later block. Transactions too large for any block are dropped.
+func (p *TxPool) fit(txs []*blockchain.Transaction) ([]*blockchain.Transaction, []*blockchain.Transaction) {
+	var (
+		selected, rest []*blockchain.Transaction
+		weight, sigOps int
+	)
+
+	for _, tx := range txs {
+		txWeight, txSigOps := tx.Weight(), tx.SigOps()
+		if exceeds(txWeight, p.maxWeight) || exceeds(txSigOps, p.maxSigOps) {
+			continue
+		}
+		if exceeds(weight+txWeight, p.maxWeight) || exceeds(sigOps+txSigOps, p.maxSigOps) {
+			rest = append(rest, tx)
+			continue
+		}
+
+		selected = append(selected, tx)
+		weight += txWeight
+		sigOps += txSigOps
+	}
+
+	return selected, rest
+}
+
+func exceeds(value, limit int) bool {
+	return limit > 0 && value > limit
+}
@@ -0,0 +1,47 @@
+package miner
+
+import (
+	"blockchain/pkg/blockchain"
+)
+
+// GetBlockTemplate returns work for miners hashing outside of this process.
+// The block pays the miner's wallet and includes pending transactions,
+// which stay in the pool until a block confirming them is submitted.
+func (m *Miner) GetBlockTemplate() (*blockchain.BlockTemplate, error) {
+	cbTx, err := blockchain.CoinbaseTx(m.walletAddr, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// right after a block is connected the pool may still hold the
+	// transactions it confirmed, until followChain removes them
+	var txs []*blockchain.Transaction
+	for _, tx := range m.pool.Pending(m.pool.packSize) {
+		if m.chain.VerifyTransaction(tx) {
+			txs = append(txs, tx)
+		}
+	}
+
+	return m.chain.NewBlockTemplate(cbTx, txs)
+}
+
+// Subscribe returns a subscription to the events of the chain mined on
+func (m *Miner) Subscribe(opts ...blockchain.SubscribeOpt) *blockchain.Subscription {
+	return m.chain.Subscribe(opts...)
+}
+
+// SubmitBlock adds a block solved from a template and relays it to the
+// peers, followChain updates the pool
+func (m *Miner) SubmitBlock(block *blockchain.Block) error {
+	if err := m.chain.SubmitBlock(block); err != nil {
+		return err
+	}
+
+	blkData, err := block.Serialize()
+	if err != nil {
+		return err
+	}
+	m.broadcastCommand("block", blkData)
+
+	return nil
+}
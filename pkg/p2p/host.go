@@ -3,7 +3,7 @@ package p2p
 import (
 	"bufio"
 	"context"
-	"crypto/ecdsa"
+	"fmt"
 
 	"github.com/libp2p/go-libp2p"
 	"github.com/libp2p/go-libp2p/core/crypto"
@@ -12,15 +12,17 @@ import (
 	"github.com/libp2p/go-libp2p/core/peerstore"
 	"github.com/libp2p/go-libp2p/core/protocol"
 	"github.com/multiformats/go-multiaddr"
+
+	bcrypto "blockchain/pkg/crypto"
 )
 
-func MakeHost(nodeAddr string, privKey *ecdsa.PrivateKey) (host.Host, error) {
+func MakeHost(nodeAddr string, privKey bcrypto.Signer) (host.Host, error) {
 	sourceMultiAddr, err := multiaddr.NewMultiaddr(nodeAddr)
 	if err != nil {
 		return nil, err
 	}
 
-	key, _, err := crypto.ECDSAKeyPairFromKey(privKey)
+	key, err := identityKey(privKey)
 	if err != nil {
 		return nil, err
 	}
@@ -28,6 +30,21 @@ func MakeHost(nodeAddr string, privKey *ecdsa.PrivateKey) (host.Host, error) {
 	return libp2p.New(libp2p.ListenAddrs(sourceMultiAddr), libp2p.Identity(key))
 }
 
+// identityKey converts a wallet key into the libp2p key of the same type
+func identityKey(privKey bcrypto.Signer) (crypto.PrivKey, error) {
+	switch k := privKey.(type) {
+	case *bcrypto.ECDSAPrivateKey:
+		key, _, err := crypto.ECDSAKeyPairFromKey(k.Key)
+		return key, err
+	case *bcrypto.Ed25519PrivateKey:
+		return crypto.UnmarshalEd25519PrivateKey(k.Key)
+	case *bcrypto.SchnorrPrivateKey:
+		return crypto.UnmarshalSecp256k1PrivateKey(k.Key.Serialize())
+	default:
+		return nil, fmt.Errorf("unsupported identity key type %T", privKey)
+	}
+}
+
 func StartPeerAndConnect(ctx context.Context, h host.Host, dest string, pid protocol.ID) (*bufio.ReadWriter, error) {
 	select {
 	case <-ctx.Done():
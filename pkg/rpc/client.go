@@ -0,0 +1,162 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"blockchain/pkg/blockchain"
+	"blockchain/pkg/wallet"
+)
+
+var _ blockchain.UTXOSource = (*Client)(nil)
+
+// Client calls the methods of a Server. It is a blockchain.UTXOSource, so
+// transactions can be funded from the UTXO set of the node.
+type Client struct {
+	url            string
+	user, password string
+
+	http *http.Client
+}
+
+type ClientOpt func(*Client)
+
+// WithCredentials authenticates the requests of the client, see WithBasicAuth
+func WithCredentials(user, password string) ClientOpt {
+	return func(c *Client) {
+		c.user, c.password = user, password
+	}
+}
+
+// NewClient returns a client of the server at addr, a host:port or a URL
+func NewClient(addr string, opts ...ClientOpt) *Client {
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+
+	c := &Client{
+		url:  addr,
+		http: &http.Client{Timeout: 30 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Call calls method with the positional params and decodes its result into
+// result. The error of a failed call is an *Error.
+func (c *Client) Call(method string, result interface{}, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(&request{
+		JSONRPC: version,
+		Method:  method,
+		Params:  rawParams,
+		ID:      json.RawMessage("1"),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.user != "" || c.password != "" {
+		req.SetBasicAuth(c.user, c.password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("rpc request failed: %s", resp.Status)
+	}
+
+	var res struct {
+		Result json.RawMessage `json:"result"`
+		Error  *Error          `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return fmt.Errorf("invalid rpc response: %w", err)
+	}
+	if res.Error != nil {
+		return res.Error
+	}
+	if result == nil {
+		return nil
+	}
+
+	return json.Unmarshal(res.Result, result)
+}
+
+// FindSpendableUTXOs returns the outputs of pubKeyHash the node can spend
+// to pay amount, and their value. pubKeyHash is of a local wallet or of a
+// multisig script.
+func (c *Client) FindSpendableUTXOs(pubKeyHash []byte, amount int) (int, map[string][]int, error) {
+	address, err := wallet.AddressFromPubKeyHash(pubKeyHash)
+	if err != nil {
+		address = wallet.MultisigAddress(pubKeyHash)
+	}
+
+	res := &unspentResult{}
+	if err := c.Call(MethodListUnspent, res, address, amount); err != nil {
+		return 0, nil, err
+	}
+
+	return res.Amount, res.Outputs, nil
+}
+
+// FindTransaction returns a transaction of the chain or the mempool of the node
+func (c *Client) FindTransaction(ID []byte) (*blockchain.Transaction, error) {
+	var raw string
+	if err := c.Call(MethodGetRawTransaction, &raw, hex.EncodeToString(ID)); err != nil {
+		var rpcErr *Error
+		if errors.As(err, &rpcErr) && rpcErr.Code == CodeNotFound {
+			return nil, fmt.Errorf("%w: %s", blockchain.ErrorTxNotFound, rpcErr.Message)
+		}
+		return nil, err
+	}
+
+	data, err := hex.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid raw transaction: %w", err)
+	}
+	tx := &blockchain.Transaction{}
+	if err := tx.Deserialize(data); err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// SendTransaction submits tx to the mempool of the node and returns its id
+func (c *Client) SendTransaction(tx *blockchain.Transaction) ([]byte, error) {
+	data, err := tx.Serialize()
+	if err != nil {
+		return nil, err
+	}
+
+	var txID string
+	if err := c.Call(MethodSendRawTransaction, &txID, hex.EncodeToString(data)); err != nil {
+		return nil, err
+	}
+
+	return hex.DecodeString(txID)
+}
@@ -0,0 +1,371 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"blockchain/pkg/blockchain"
+	"blockchain/pkg/wallet"
+)
+
+type blockResult struct {
+	Hash string `json:"hash"`
+	// Confirmations is -1 for a block off the main chain
+	Confirmations int      `json:"confirmations"`
+	Height        int      `json:"height"`
+	PrevHash      string   `json:"prev_hash"`
+	NextHash      string   `json:"next_hash,omitempty"`
+	Timestamp     int64    `json:"timestamp"`
+	Difficulty    int      `json:"difficulty"`
+	Nonce         int      `json:"nonce"`
+	TxRoot        string   `json:"tx_root"`
+	WitnessRoot   string   `json:"witness_root"`
+	Weight        int      `json:"weight"`
+	SigOps        int      `json:"sigops"`
+	Transactions  []string `json:"tx"`
+}
+
+type txInputResult struct {
+	TxID     string `json:"txid,omitempty"`
+	Out      int    `json:"vout"`
+	Coinbase bool   `json:"coinbase,omitempty"`
+}
+
+type txOutputResult struct {
+	Value      int    `json:"value"`
+	Type       string `json:"type"`
+	PubKeyHash string `json:"pubkeyhash,omitempty"`
+	Data       string `json:"data,omitempty"`
+}
+
+type txResult struct {
+	TxID string `json:"txid"`
+	Hex  string `json:"hex"`
+	// BlockHash and Height are left out for a transaction of the mempool
+	BlockHash     string           `json:"block_hash,omitempty"`
+	Height        int              `json:"height,omitempty"`
+	Confirmations int              `json:"confirmations"`
+	LockTime      int64            `json:"locktime"`
+	Weight        int              `json:"weight"`
+	Inputs        []txInputResult  `json:"vin"`
+	Outputs       []txOutputResult `json:"vout"`
+}
+
+type unspentResult struct {
+	// Amount is the value of the outputs
+	Amount int `json:"amount"`
+	// Outputs holds the indexes of the outputs by txid
+	Outputs map[string][]int `json:"outputs"`
+}
+
+type mempoolResult struct {
+	Size     int `json:"size"`
+	NonFinal int `json:"nonfinal"`
+	Weight   int `json:"weight"`
+}
+
+type peerResult struct {
+	Addr string `json:"addr"`
+}
+
+func (s *Server) tipHeight() (int, error) {
+	tip, err := s.chain.GetHeader(s.chain.LastHash())
+	if err != nil {
+		return 0, err
+	}
+
+	return tip.Height, nil
+}
+
+func (s *Server) getBlockCount(params json.RawMessage) (interface{}, error) {
+	if err := parseParams(params, 0); err != nil {
+		return nil, err
+	}
+
+	return s.tipHeight()
+}
+
+func (s *Server) getBestBlockHash(params json.RawMessage) (interface{}, error) {
+	if err := parseParams(params, 0); err != nil {
+		return nil, err
+	}
+
+	return hex.EncodeToString(s.chain.LastHash()), nil
+}
+
+func (s *Server) getBlockHash(params json.RawMessage) (interface{}, error) {
+	var height int
+	if err := parseParams(params, 1, &height); err != nil {
+		return nil, err
+	}
+
+	hash, err := s.chain.BlockHash(height)
+	if err != nil {
+		return nil, err
+	}
+
+	return hex.EncodeToString(hash), nil
+}
+
+func (s *Server) getBlock(params json.RawMessage) (interface{}, error) {
+	var (
+		id      json.RawMessage
+		verbose = true
+	)
+	if err := parseParams(params, 1, &id, &verbose); err != nil {
+		return nil, err
+	}
+
+	block, err := s.lookupBlock(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !verbose {
+		data, err := block.Serialize()
+		if err != nil {
+			return nil, err
+		}
+		return hex.EncodeToString(data), nil
+	}
+
+	result := &blockResult{
+		Hash:          hex.EncodeToString(block.Hash),
+		Confirmations: -1,
+		Height:        block.Height,
+		PrevHash:      hex.EncodeToString(block.PrevHash),
+		Timestamp:     block.Timestamp,
+		Difficulty:    block.Difficulty,
+		Nonce:         block.Nonce,
+		TxRoot:        hex.EncodeToString(block.TxRoot),
+		WitnessRoot:   hex.EncodeToString(block.WitnessRoot),
+		Weight:        block.Weight(),
+		SigOps:        block.SigOps(),
+		Transactions:  make([]string, 0, len(block.Transactions)),
+	}
+	for _, tx := range block.Transactions {
+		result.Transactions = append(result.Transactions, hex.EncodeToString(tx.ID))
+	}
+
+	if hash, err := s.chain.BlockHash(block.Height); err == nil && bytes.Equal(hash, block.Hash) {
+		height, err := s.tipHeight()
+		if err != nil {
+			return nil, err
+		}
+		result.Confirmations = height - block.Height + 1
+		if next, err := s.chain.BlockHash(block.Height + 1); err == nil {
+			result.NextHash = hex.EncodeToString(next)
+		}
+	}
+
+	return result, nil
+}
+
+// lookupBlock returns the block with the hash, or the main chain block at
+// the height, id holds
+func (s *Server) lookupBlock(id json.RawMessage) (*blockchain.Block, error) {
+	var height int
+	if err := json.Unmarshal(id, &height); err == nil {
+		hash, err := s.chain.BlockHash(height)
+		if err != nil {
+			return nil, err
+		}
+		return s.chain.GetBlock(hash)
+	}
+
+	var hash string
+	if err := json.Unmarshal(id, &hash); err != nil {
+		return nil, newError(CodeInvalidParams, "block must be a hash or a height")
+	}
+	b, err := hex.DecodeString(hash)
+	if err != nil || len(b) == 0 {
+		return nil, newError(CodeInvalidParams, "invalid block hash %q", hash)
+	}
+
+	return s.chain.GetBlock(b)
+}
+
+func (s *Server) getRawTransaction(params json.RawMessage) (interface{}, error) {
+	var (
+		txID    string
+		verbose bool
+	)
+	if err := parseParams(params, 1, &txID, &verbose); err != nil {
+		return nil, err
+	}
+	id, err := hex.DecodeString(txID)
+	if err != nil || len(id) == 0 {
+		return nil, newError(CodeInvalidParams, "invalid transaction id %q", txID)
+	}
+
+	tx, block, err := s.chain.FindTransactionBlock(id)
+	if errors.Is(err, blockchain.ErrorTxNotFound) && s.mempool != nil {
+		if pending := s.mempool.Lookup(id); pending != nil {
+			tx, err = pending, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := tx.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	if !verbose {
+		return hex.EncodeToString(data), nil
+	}
+
+	result := &txResult{
+		TxID:     hex.EncodeToString(tx.ID),
+		Hex:      hex.EncodeToString(data),
+		LockTime: tx.LockTime,
+		Weight:   tx.Weight(),
+		Inputs:   make([]txInputResult, 0, len(tx.Inputs)),
+		Outputs:  make([]txOutputResult, 0, len(tx.Outputs)),
+	}
+	if block != nil {
+		height, err := s.tipHeight()
+		if err != nil {
+			return nil, err
+		}
+		result.BlockHash = hex.EncodeToString(block.Hash)
+		result.Height = block.Height
+		result.Confirmations = height - block.Height + 1
+	}
+	for _, in := range tx.Inputs {
+		if tx.IsCoinbase() {
+			result.Inputs = append(result.Inputs, txInputResult{Out: in.Out, Coinbase: true})
+			continue
+		}
+		result.Inputs = append(result.Inputs, txInputResult{TxID: hex.EncodeToString(in.ID), Out: in.Out})
+	}
+	for _, out := range tx.Outputs {
+		result.Outputs = append(result.Outputs, txOutputResult{
+			Value:      out.Value,
+			Type:       out.Type.String(),
+			PubKeyHash: hex.EncodeToString(out.PubKeyHash),
+			Data:       hex.EncodeToString(out.Data),
+		})
+	}
+
+	return result, nil
+}
+
+func (s *Server) sendRawTransaction(params json.RawMessage) (interface{}, error) {
+	var raw string
+	if err := parseParams(params, 1, &raw); err != nil {
+		return nil, err
+	}
+	if s.node == nil {
+		return nil, newError(CodeUnavailable, "no node attached")
+	}
+
+	data, err := hex.DecodeString(raw)
+	if err != nil {
+		return nil, newError(CodeInvalidParams, "transaction must be hex encoded")
+	}
+	tx := &blockchain.Transaction{}
+	if err := tx.Deserialize(data); err != nil {
+		return nil, newError(CodeTxRejected, "invalid transaction: %s", err)
+	}
+	if !bytes.Equal(tx.ID, tx.Hash()) {
+		return nil, newError(CodeTxRejected, "transaction id %x does not match its content", tx.ID)
+	}
+	if tx.IsCoinbase() {
+		return nil, newError(CodeTxRejected, "coinbase transactions are only valid in a block")
+	}
+
+	if err := s.node.SubmitTransaction(tx); err != nil {
+		return nil, newError(CodeTxRejected, "%s", err)
+	}
+
+	return hex.EncodeToString(tx.ID), nil
+}
+
+func (s *Server) getBalance(params json.RawMessage) (interface{}, error) {
+	var address string
+	if err := parseParams(params, 1, &address); err != nil {
+		return nil, err
+	}
+	pubKeyHash, err := wallet.PubKeyHashFromAddress(address)
+	if err != nil {
+		return nil, newError(CodeInvalidParams, "invalid address %q", address)
+	}
+
+	outs, err := s.utxoSet.FindUTXOs(pubKeyHash)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading utxo set: %w", err)
+	}
+
+	balance := 0
+	for _, out := range *outs {
+		balance += out.Value
+	}
+
+	return balance, nil
+}
+
+func (s *Server) listUnspent(params json.RawMessage) (interface{}, error) {
+	var (
+		address string
+		amount  int
+	)
+	if err := parseParams(params, 1, &address, &amount); err != nil {
+		return nil, err
+	}
+	pubKeyHash, err := wallet.PubKeyHashFromAddress(address)
+	if err != nil {
+		return nil, newError(CodeInvalidParams, "invalid address %q", address)
+	}
+	if amount <= 0 {
+		amount = math.MaxInt
+	}
+
+	acc, outputs, err := s.utxoSet.FindSpendableUTXOs(pubKeyHash, amount)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading utxo set: %w", err)
+	}
+
+	return &unspentResult{Amount: acc, Outputs: outputs}, nil
+}
+
+func (s *Server) getMempoolInfo(params json.RawMessage) (interface{}, error) {
+	if err := parseParams(params, 0); err != nil {
+		return nil, err
+	}
+	if s.mempool == nil {
+		return nil, newError(CodeUnavailable, "no mempool attached")
+	}
+
+	pending := s.mempool.All()
+	result := &mempoolResult{
+		Size:     len(pending),
+		NonFinal: s.mempool.NonFinalCount(),
+	}
+	for _, tx := range pending {
+		result.Weight += tx.Weight()
+	}
+
+	return result, nil
+}
+
+func (s *Server) getPeerInfo(params json.RawMessage) (interface{}, error) {
+	if err := parseParams(params, 0); err != nil {
+		return nil, err
+	}
+	if s.node == nil {
+		return nil, newError(CodeUnavailable, "no node attached")
+	}
+
+	peers := make([]peerResult, 0)
+	for _, addr := range s.node.Peers() {
+		peers = append(peers, peerResult{Addr: addr})
+	}
+
+	return peers, nil
+}
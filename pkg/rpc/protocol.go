@@ -0,0 +1,112 @@
+// Package rpc serves JSON-RPC 2.0 over HTTP to control a running node,
+// so services don't have to open its block database.
+//
+// Requests are POSTed as JSON, one at a time or in a batch. Params are
+// positional:
+//
+//	getblockcount      []                     tip height
+//	getbestblockhash   []                     tip hash
+//	getblockhash       [height]               hash of the main chain block at height
+//	getblock           [hash|height, verbose] block, hex encoded unless verbose (default)
+//	getrawtransaction  [txid, verbose]        transaction, hex encoded unless verbose
+//	sendrawtransaction [hex]                  txid of the transaction added to the mempool
+//	getbalance         [address]              value of the unspent outputs of address
+//	listunspent        [address, amount]      unspent outputs of address, enough to pay amount if given
+//	getmempoolinfo     []                     size of the mempool
+//	getpeerinfo        []                     connected peers
+//
+// Hashes and ids are hex. Raw blocks and transactions are their serialized
+// form, hex encoded.
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	MethodGetBlockCount      = "getblockcount"
+	MethodGetBestBlockHash   = "getbestblockhash"
+	MethodGetBlockHash       = "getblockhash"
+	MethodGetBlock           = "getblock"
+	MethodGetRawTransaction  = "getrawtransaction"
+	MethodSendRawTransaction = "sendrawtransaction"
+	MethodGetBalance         = "getbalance"
+	MethodListUnspent        = "listunspent"
+	MethodGetMempoolInfo     = "getmempoolinfo"
+	MethodGetPeerInfo        = "getpeerinfo"
+)
+
+// Error codes of JSON-RPC 2.0, then those of this server
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternal       = -32603
+
+	// CodeNotFound is returned for unknown blocks, transactions and heights
+	CodeNotFound = -32001
+	// CodeTxRejected is returned when sendrawtransaction can't accept a transaction
+	CodeTxRejected = -32002
+	// CodeUnavailable is returned for mempool and peer methods when the
+	// server has no mempool or node attached
+	CodeUnavailable = -32003
+)
+
+const version = "2.0"
+
+// Error is the error object of a response
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+func newError(code int, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// request is a call, or a notification when ID is absent
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// parseParams decodes the positional params into dst, of which the first
+// required ones must be given
+func parseParams(params json.RawMessage, required int, dst ...interface{}) error {
+	var fields []json.RawMessage
+	if len(params) > 0 && string(params) != "null" {
+		if err := json.Unmarshal(params, &fields); err != nil {
+			return newError(CodeInvalidParams, "params must be an array")
+		}
+	}
+
+	if len(fields) < required || len(fields) > len(dst) {
+		if required == len(dst) {
+			return newError(CodeInvalidParams, "expected %d params, got %d", required, len(fields))
+		}
+		return newError(CodeInvalidParams, "expected %d to %d params, got %d", required, len(dst), len(fields))
+	}
+
+	for i, field := range fields {
+		if err := json.Unmarshal(field, dst[i]); err != nil {
+			return newError(CodeInvalidParams, "invalid param %d: %s", i, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,288 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"blockchain/pkg/blockchain"
+)
+
+// maxRequestSize bounds the body of a request, a batch included
+const maxRequestSize = 1 << 20
+
+// ErrorNoCredentials is returned when serving a non loopback address
+// without WithBasicAuth, anyone reaching it could control the node
+var ErrorNoCredentials = errors.New("rpc server needs credentials to listen on a non loopback address")
+
+// Mempool is the pool of unconfirmed transactions of the node, a *miner.TxPool
+type Mempool interface {
+	// All returns every transaction ready for a block
+	All() []*blockchain.Transaction
+	// Lookup returns the transaction with the id, or nil if the pool has none
+	Lookup(id []byte) *blockchain.Transaction
+	// NonFinalCount returns the number of transactions waiting for their lock time
+	NonFinalCount() int
+}
+
+// Node is the running node, a *miner.Miner
+type Node interface {
+	// SubmitTransaction verifies tx, adds it to the mempool and relays it to the peers
+	SubmitTransaction(tx *blockchain.Transaction) error
+	// Peers returns the multiaddress of each connected peer
+	Peers() []string
+}
+
+type method func(params json.RawMessage) (interface{}, error)
+
+type Server struct {
+	chain   *blockchain.BlockChain
+	utxoSet *blockchain.UTXOSet
+	mempool Mempool
+	node    Node
+
+	user, password string
+
+	methods map[string]method
+}
+
+type ServerOpt func(*Server)
+
+// WithMempool serves getmempoolinfo and lets getrawtransaction find
+// unconfirmed transactions
+func WithMempool(pool Mempool) ServerOpt {
+	return func(s *Server) {
+		s.mempool = pool
+	}
+}
+
+// WithNode serves sendrawtransaction and getpeerinfo
+func WithNode(node Node) ServerOpt {
+	return func(s *Server) {
+		s.node = node
+	}
+}
+
+// WithBasicAuth only serves requests authenticated with user and password.
+// Without it the server only listens on and answers loopback addresses.
+func WithBasicAuth(user, password string) ServerOpt {
+	return func(s *Server) {
+		s.user, s.password = user, password
+	}
+}
+
+// NewServer returns a server of chain. Methods needing a mempool or a node
+// fail with CodeUnavailable unless WithMempool and WithNode are given.
+func NewServer(chain *blockchain.BlockChain, opts ...ServerOpt) *Server {
+	s := &Server{
+		chain:   chain,
+		utxoSet: blockchain.NewUTXOSet(chain),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.methods = map[string]method{
+		MethodGetBlockCount:      s.getBlockCount,
+		MethodGetBestBlockHash:   s.getBestBlockHash,
+		MethodGetBlockHash:       s.getBlockHash,
+		MethodGetBlock:           s.getBlock,
+		MethodGetRawTransaction:  s.getRawTransaction,
+		MethodSendRawTransaction: s.sendRawTransaction,
+		MethodGetBalance:         s.getBalance,
+		MethodListUnspent:        s.listUnspent,
+		MethodGetMempoolInfo:     s.getMempoolInfo,
+		MethodGetPeerInfo:        s.getPeerInfo,
+	}
+
+	return s
+}
+
+// ListenAndServe listens on addr and serves requests until ctx is done
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(ctx, ln)
+}
+
+// Serve serves requests on ln until ctx is done
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	if !s.hasCredentials() && !isLoopback(ln.Addr()) {
+		ln.Close()
+		return fmt.Errorf("%w: %s", ErrorNoCredentials, ln.Addr())
+	}
+
+	srv := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	err := srv.Serve(ln)
+	if errors.Is(err, http.ErrServerClosed) {
+		return ctx.Err()
+	}
+
+	return err
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "JSON-RPC requests must be POSTed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="rpc"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var result interface{}
+	if body = bytes.TrimSpace(body); len(body) > 0 && body[0] == '[' {
+		result = s.handleBatch(body)
+	} else if resp := s.handle(body); resp != nil {
+		result = resp
+	}
+
+	// a request made of notifications only gets no response
+	if result == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+func (s *Server) hasCredentials() bool {
+	return s.user != "" || s.password != ""
+}
+
+// authorized reports whether r carries the credentials of the server, or
+// comes from a loopback address if it has none
+func (s *Server) authorized(r *http.Request) bool {
+	if !s.hasCredentials() {
+		// Serve only accepts such a server on a loopback or local listener,
+		// requests over a unix socket carry no IP address
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		ip := net.ParseIP(host)
+		return ip == nil || ip.IsLoopback()
+	}
+
+	user, password, ok := r.BasicAuth()
+
+	return ok &&
+		subtle.ConstantTimeCompare([]byte(user), []byte(s.user)) == 1 &&
+		subtle.ConstantTimeCompare([]byte(password), []byte(s.password)) == 1
+}
+
+func (s *Server) handleBatch(body []byte) interface{} {
+	var reqs []json.RawMessage
+	if err := json.Unmarshal(body, &reqs); err != nil {
+		return errorResponse(nil, newError(CodeParseError, "%s", err))
+	}
+	if len(reqs) == 0 {
+		return errorResponse(nil, newError(CodeInvalidRequest, "empty batch"))
+	}
+
+	var resps []*response
+	for _, req := range reqs {
+		if resp := s.handle(req); resp != nil {
+			resps = append(resps, resp)
+		}
+	}
+	if len(resps) == 0 {
+		return nil
+	}
+
+	return resps
+}
+
+// handle runs a single request, it returns no response for a notification
+func (s *Server) handle(data []byte) *response {
+	req := &request{}
+	if err := json.Unmarshal(data, req); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return errorResponse(nil, newError(CodeParseError, "%s", err))
+		}
+		return errorResponse(nil, newError(CodeInvalidRequest, "%s", err))
+	}
+	if req.JSONRPC != version || req.Method == "" {
+		return errorResponse(req.ID, newError(CodeInvalidRequest, "expected jsonrpc %q and a method", version))
+	}
+
+	m, ok := s.methods[req.Method]
+	if !ok {
+		if req.ID == nil {
+			return nil
+		}
+		return errorResponse(req.ID, newError(CodeMethodNotFound, "method %s not found", req.Method))
+	}
+
+	result, err := m(req.Params)
+	if req.ID == nil {
+		return nil
+	}
+	if err != nil {
+		return errorResponse(req.ID, toError(err))
+	}
+
+	return &response{JSONRPC: version, Result: result, ID: req.ID}
+}
+
+func errorResponse(id json.RawMessage, err *Error) *response {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+
+	return &response{JSONRPC: version, Error: err, ID: id}
+}
+
+// toError turns the error of a method into the error object of its response
+func toError(err error) *Error {
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr
+	}
+
+	if errors.Is(err, blockchain.ErrorBlkNotFound) || errors.Is(err, blockchain.ErrorTxNotFound) {
+		return newError(CodeNotFound, "%s", err)
+	}
+
+	return newError(CodeInternal, "%s", err)
+}
+
+// isLoopback reports whether addr only accepts connections from the host itself
+func isLoopback(addr net.Addr) bool {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		// pipes and unix sockets are not reachable from the network
+		return addr.Network() != "tcp" && addr.Network() != "udp"
+	}
+
+	return tcpAddr.IP.IsLoopback()
+}
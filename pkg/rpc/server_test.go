@@ -0,0 +1,509 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"blockchain/pkg/blockchain"
+	"blockchain/pkg/crypto"
+	"blockchain/pkg/wallet"
+)
+
+// testMempool holds transactions ready for a block and ones waiting for
+// their lock time, like a *miner.TxPool
+type testMempool struct {
+	txs, nonFinal []*blockchain.Transaction
+}
+
+func (p *testMempool) All() []*blockchain.Transaction {
+	return p.txs
+}
+
+func (p *testMempool) Lookup(id []byte) *blockchain.Transaction {
+	for _, tx := range append(p.txs, p.nonFinal...) {
+		if bytes.Equal(tx.ID, id) {
+			return tx
+		}
+	}
+
+	return nil
+}
+
+func (p *testMempool) NonFinalCount() int {
+	return len(p.nonFinal)
+}
+
+// testNode accepts transactions unless err is set
+type testNode struct {
+	submitted []*blockchain.Transaction
+	err       error
+	peers     []string
+}
+
+func (n *testNode) SubmitTransaction(tx *blockchain.Transaction) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.submitted = append(n.submitted, tx)
+
+	return nil
+}
+
+func (n *testNode) Peers() []string {
+	return n.peers
+}
+
+// testChain is a chain whose genesis pays alice, and whose block at
+// height 1 holds tx, paying 5 to bob
+type testChain struct {
+	*blockchain.BlockChain
+	alice, bob string
+	block      *blockchain.Block
+	tx         *blockchain.Transaction
+}
+
+func newTestWallet(t *testing.T) string {
+	t.Helper()
+
+	address, err := wallet.CreateWallet(crypto.KeyECDSA)
+	if err != nil {
+		t.Fatalf("CreateWallet: %v", err)
+	}
+
+	return address
+}
+
+func newTestChain(t *testing.T) *testChain {
+	t.Helper()
+
+	c := &testChain{alice: newTestWallet(t), bob: newTestWallet(t)}
+
+	var err error
+	c.BlockChain, err = blockchain.InitBlockChain(c.alice, blockchain.WithDataDir(t.TempDir()))
+	if err != nil {
+		t.Fatalf("InitBlockChain: %v", err)
+	}
+	t.Cleanup(c.Close)
+
+	if c.tx, err = blockchain.NewTransaction(c.alice, c.bob, 5, blockchain.NewUTXOSet(c.BlockChain)); err != nil {
+		t.Fatalf("NewTransaction: %v", err)
+	}
+	cbTx, err := blockchain.CoinbaseTx(c.alice, "")
+	if err != nil {
+		t.Fatalf("CoinbaseTx: %v", err)
+	}
+	if c.block, err = c.MineBlock([]*blockchain.Transaction{c.tx, cbTx}); err != nil {
+		t.Fatalf("MineBlock: %v", err)
+	}
+
+	return c
+}
+
+// newTestTx returns a transaction of alice which is not in the chain
+func (c *testChain) newTestTx(t *testing.T) *blockchain.Transaction {
+	t.Helper()
+
+	tx, err := blockchain.NewTransaction(c.alice, c.bob, 1, blockchain.NewUTXOSet(c.BlockChain))
+	if err != nil {
+		t.Fatalf("NewTransaction: %v", err)
+	}
+
+	return tx
+}
+
+// newTestClient serves chain on a loopback address and returns a client of it
+func newTestClient(t *testing.T, chain *blockchain.BlockChain, opts ...ServerOpt) (*Client, string) {
+	t.Helper()
+
+	ts := httptest.NewServer(NewServer(chain, opts...))
+	t.Cleanup(ts.Close)
+
+	return NewClient(ts.URL), ts.URL
+}
+
+func expectCode(t *testing.T, err error, code int) {
+	t.Helper()
+
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code != code {
+		t.Errorf("got error %v, want code %d", err, code)
+	}
+}
+
+func TestChainMethods(t *testing.T) {
+	chain := newTestChain(t)
+	client, _ := newTestClient(t, chain.BlockChain)
+	blockHash := hex.EncodeToString(chain.block.Hash)
+
+	var height int
+	if err := client.Call(MethodGetBlockCount, &height); err != nil || height != 1 {
+		t.Errorf("getblockcount = %d, %v, want 1", height, err)
+	}
+	expectCode(t, client.Call(MethodGetBlockCount, nil, 1), CodeInvalidParams)
+
+	var hash string
+	if err := client.Call(MethodGetBestBlockHash, &hash); err != nil || hash != blockHash {
+		t.Errorf("getbestblockhash = %s, %v, want %s", hash, err, blockHash)
+	}
+	if err := client.Call(MethodGetBlockHash, &hash, 1); err != nil || hash != blockHash {
+		t.Errorf("getblockhash 1 = %s, %v, want %s", hash, err, blockHash)
+	}
+	expectCode(t, client.Call(MethodGetBlockHash, &hash, 2), CodeNotFound)
+	expectCode(t, client.Call(MethodGetBlockHash, &hash, "one"), CodeInvalidParams)
+	expectCode(t, client.Call(MethodGetBlockHash, &hash), CodeInvalidParams)
+
+	genesis := &blockResult{}
+	if err := client.Call(MethodGetBlock, genesis, 0); err != nil {
+		t.Fatalf("getblock 0: %v", err)
+	}
+	if genesis.Height != 0 || genesis.Confirmations != 2 || genesis.NextHash != blockHash {
+		t.Errorf("getblock 0 = %+v, want 2 confirmations and next block %s", genesis, blockHash)
+	}
+
+	block := &blockResult{}
+	if err := client.Call(MethodGetBlock, block, blockHash); err != nil {
+		t.Fatalf("getblock %s: %v", blockHash, err)
+	}
+	if block.Height != 1 || block.Confirmations != 1 || block.PrevHash != genesis.Hash || block.NextHash != "" {
+		t.Errorf("getblock %s = %+v, want the tip on top of genesis", blockHash, block)
+	}
+	if len(block.Transactions) != 2 || block.Transactions[0] != hex.EncodeToString(chain.tx.ID) {
+		t.Errorf("transactions of block = %v, want %x and the coinbase", block.Transactions, chain.tx.ID)
+	}
+
+	var raw string
+	if err := client.Call(MethodGetBlock, &raw, blockHash, false); err != nil {
+		t.Fatalf("getblock %s false: %v", blockHash, err)
+	}
+	data, err := hex.DecodeString(raw)
+	if err != nil {
+		t.Fatalf("raw block: %v", err)
+	}
+	decoded := &blockchain.Block{}
+	if err := decoded.Deserialize(data); err != nil || !bytes.Equal(decoded.Hash, chain.block.Hash) {
+		t.Errorf("raw block decodes to %x, %v, want %s", decoded.Hash, err, blockHash)
+	}
+
+	expectCode(t, client.Call(MethodGetBlock, block, strings.Repeat("00", 32)), CodeNotFound)
+	expectCode(t, client.Call(MethodGetBlock, block, "not hex"), CodeInvalidParams)
+	expectCode(t, client.Call(MethodGetBlock, block, true), CodeInvalidParams)
+}
+
+func TestUTXOMethods(t *testing.T) {
+	chain := newTestChain(t)
+	client, _ := newTestClient(t, chain.BlockChain)
+
+	var balance int
+	if err := client.Call(MethodGetBalance, &balance, chain.bob); err != nil || balance != 5 {
+		t.Errorf("getbalance of bob = %d, %v, want 5", balance, err)
+	}
+	expectCode(t, client.Call(MethodGetBalance, &balance, "not an address"), CodeInvalidParams)
+
+	unspent := &unspentResult{}
+	if err := client.Call(MethodListUnspent, unspent, chain.bob); err != nil {
+		t.Fatalf("listunspent: %v", err)
+	}
+	outs := unspent.Outputs[hex.EncodeToString(chain.tx.ID)]
+	if unspent.Amount != 5 || len(unspent.Outputs) != 1 || len(outs) != 1 {
+		t.Errorf("listunspent of bob = %+v, want the output of %x", unspent, chain.tx.ID)
+	}
+	expectCode(t, client.Call(MethodListUnspent, unspent, "not an address"), CodeInvalidParams)
+
+	// the client funds transactions from the node like a local UTXO set
+	tx, err := blockchain.NewTransaction(chain.bob, chain.alice, 3, client)
+	if err != nil {
+		t.Fatalf("NewTransaction through the client: %v", err)
+	}
+	if !chain.VerifyTransaction(tx) {
+		t.Error("transaction funded through the client does not verify")
+	}
+}
+
+func TestTransactionMethods(t *testing.T) {
+	chain := newTestChain(t)
+	pending, waiting := chain.newTestTx(t), chain.newTestTx(t)
+	pool := &testMempool{
+		txs:      []*blockchain.Transaction{pending},
+		nonFinal: []*blockchain.Transaction{waiting},
+	}
+	client, _ := newTestClient(t, chain.BlockChain, WithMempool(pool))
+	txID := hex.EncodeToString(chain.tx.ID)
+
+	res := &txResult{}
+	if err := client.Call(MethodGetRawTransaction, res, txID, true); err != nil {
+		t.Fatalf("getrawtransaction %s: %v", txID, err)
+	}
+	if res.TxID != txID || res.BlockHash != hex.EncodeToString(chain.block.Hash) || res.Height != 1 || res.Confirmations != 1 {
+		t.Errorf("getrawtransaction %s = %+v, want it confirmed once at height 1", txID, res)
+	}
+	if len(res.Inputs) != len(chain.tx.Inputs) || len(res.Outputs) != len(chain.tx.Outputs) || res.Outputs[0].Value != 5 {
+		t.Errorf("getrawtransaction %s = %+v, want the inputs and outputs of the transaction", txID, res)
+	}
+
+	// confirmed and unconfirmed transactions, one waiting for its lock time too
+	for _, want := range []*blockchain.Transaction{chain.tx, pending, waiting} {
+		tx, err := client.FindTransaction(want.ID)
+		if err != nil || !bytes.Equal(tx.ID, want.ID) {
+			t.Errorf("FindTransaction %x = %v, want it", want.ID, err)
+		}
+	}
+	res = &txResult{}
+	if err := client.Call(MethodGetRawTransaction, res, hex.EncodeToString(pending.ID), true); err != nil {
+		t.Fatalf("getrawtransaction of the mempool: %v", err)
+	}
+	if res.BlockHash != "" || res.Confirmations != 0 {
+		t.Errorf("getrawtransaction of the mempool = %+v, want no block", res)
+	}
+
+	unknown := strings.Repeat("00", 32)
+	expectCode(t, client.Call(MethodGetRawTransaction, res, unknown), CodeNotFound)
+	if _, err := client.FindTransaction(make([]byte, 32)); !errors.Is(err, blockchain.ErrorTxNotFound) {
+		t.Errorf("FindTransaction of an unknown id: got %v, want %v", err, blockchain.ErrorTxNotFound)
+	}
+	expectCode(t, client.Call(MethodGetRawTransaction, res, "not hex"), CodeInvalidParams)
+
+	// without a mempool only the chain is searched
+	client, _ = newTestClient(t, chain.BlockChain)
+	expectCode(t, client.Call(MethodGetRawTransaction, res, hex.EncodeToString(pending.ID)), CodeNotFound)
+}
+
+func TestMempoolInfo(t *testing.T) {
+	chain := newTestChain(t)
+
+	client, _ := newTestClient(t, chain.BlockChain)
+	expectCode(t, client.Call(MethodGetMempoolInfo, nil), CodeUnavailable)
+
+	pool := &testMempool{nonFinal: []*blockchain.Transaction{chain.newTestTx(t)}}
+	weight := 0
+	for i := 0; i < 3; i++ {
+		tx := chain.newTestTx(t)
+		pool.txs = append(pool.txs, tx)
+		weight += tx.Weight()
+	}
+	client, _ = newTestClient(t, chain.BlockChain, WithMempool(pool))
+
+	info := &mempoolResult{}
+	if err := client.Call(MethodGetMempoolInfo, info); err != nil {
+		t.Fatalf("getmempoolinfo: %v", err)
+	}
+	if want := (mempoolResult{Size: 3, NonFinal: 1, Weight: weight}); *info != want {
+		t.Errorf("getmempoolinfo = %+v, want %+v", info, want)
+	}
+}
+
+func TestNodeMethods(t *testing.T) {
+	chain := newTestChain(t)
+	tx := chain.newTestTx(t)
+
+	client, _ := newTestClient(t, chain.BlockChain)
+	if _, err := client.SendTransaction(tx); err == nil {
+		t.Error("sendrawtransaction without a node succeeded")
+	}
+	expectCode(t, client.Call(MethodSendRawTransaction, nil, "00"), CodeUnavailable)
+	expectCode(t, client.Call(MethodGetPeerInfo, nil), CodeUnavailable)
+
+	node := &testNode{peers: []string{"/ip4/127.0.0.1/udp/4001/quic-v1/p2p/peer"}}
+	client, _ = newTestClient(t, chain.BlockChain, WithNode(node))
+
+	id, err := client.SendTransaction(tx)
+	if err != nil || !bytes.Equal(id, tx.ID) {
+		t.Fatalf("SendTransaction = %x, %v, want %x", id, err, tx.ID)
+	}
+	if len(node.submitted) != 1 || !bytes.Equal(node.submitted[0].ID, tx.ID) {
+		t.Errorf("node got %d transactions, want %x", len(node.submitted), tx.ID)
+	}
+
+	expectCode(t, client.Call(MethodSendRawTransaction, nil, "not hex"), CodeInvalidParams)
+	expectCode(t, client.Call(MethodSendRawTransaction, nil, "00ff"), CodeTxRejected)
+
+	cbTx, err := blockchain.CoinbaseTx(chain.alice, "")
+	if err != nil {
+		t.Fatalf("CoinbaseTx: %v", err)
+	}
+	tampered := *tx
+	tampered.ID = cbTx.ID
+	for _, bad := range []*blockchain.Transaction{cbTx, &tampered} {
+		_, err := client.SendTransaction(bad)
+		expectCode(t, err, CodeTxRejected)
+	}
+
+	node.err = errors.New("transaction is invalid")
+	_, err = client.SendTransaction(tx)
+	expectCode(t, err, CodeTxRejected)
+
+	var peers []peerResult
+	if err := client.Call(MethodGetPeerInfo, &peers); err != nil || len(peers) != 1 || peers[0].Addr != node.peers[0] {
+		t.Errorf("getpeerinfo = %v, %v, want %v", peers, err, node.peers)
+	}
+}
+
+// post sends body to the server at url and decodes its responses
+func post(t *testing.T, url, body string) (int, []response) {
+	t.Helper()
+
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var data json.RawMessage
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		t.Fatalf("invalid response to %s: %v", body, err)
+	}
+
+	var resps []response
+	if data[0] != '[' {
+		data = append(append(json.RawMessage("["), data...), ']')
+	}
+	if err := json.Unmarshal(data, &resps); err != nil {
+		t.Fatalf("invalid response to %s: %v", body, err)
+	}
+
+	return resp.StatusCode, resps
+}
+
+func TestRequests(t *testing.T) {
+	chain := newTestChain(t)
+	_, url := newTestClient(t, chain.BlockChain)
+
+	for _, c := range []struct {
+		body string
+		code int
+	}{
+		{`{"jsonrpc":"2.0","method":`, CodeParseError},
+		{`[{"jsonrpc":"2.0"`, CodeParseError},
+		{`{"jsonrpc":"2.0","method":1,"id":1}`, CodeInvalidRequest},
+		{`{"jsonrpc":"1.0","method":"getblockcount","id":1}`, CodeInvalidRequest},
+		{`{"jsonrpc":"2.0","id":1}`, CodeInvalidRequest},
+		{`[]`, CodeInvalidRequest},
+		{`{"jsonrpc":"2.0","method":"stop","id":1}`, CodeMethodNotFound},
+		{`{"jsonrpc":"2.0","method":"getblockhash","params":{"height":1},"id":1}`, CodeInvalidParams},
+		{`{"jsonrpc":"2.0","method":"getblock","params":[1,true,2],"id":1}`, CodeInvalidParams},
+	} {
+		status, resps := post(t, url, c.body)
+		if status != http.StatusOK || len(resps) != 1 || resps[0].Error == nil || resps[0].Error.Code != c.code {
+			t.Errorf("%s: got status %d and %+v, want error code %d", c.body, status, resps, c.code)
+		}
+	}
+
+	// notifications get no response, even for unknown methods
+	for _, body := range []string{
+		`{"jsonrpc":"2.0","method":"getblockcount"}`,
+		`{"jsonrpc":"2.0","method":"stop"}`,
+		`[{"jsonrpc":"2.0","method":"getblockcount"}]`,
+	} {
+		if status, _ := post(t, url, body); status != http.StatusNoContent {
+			t.Errorf("%s: got status %d, want %d", body, status, http.StatusNoContent)
+		}
+	}
+
+	status, resps := post(t, url, `[
+		{"jsonrpc":"2.0","method":"getblockcount","id":1},
+		{"jsonrpc":"2.0","method":"getblockcount"},
+		{"jsonrpc":"2.0","method":"getblockhash","params":[7],"id":"b"}
+	]`)
+	if status != http.StatusOK || len(resps) != 2 {
+		t.Fatalf("batch: got status %d and %d responses, want 2", status, len(resps))
+	}
+	if string(resps[0].ID) != "1" || resps[0].Error != nil {
+		t.Errorf("first response of the batch = %+v, want the block count", resps[0])
+	}
+	if string(resps[1].ID) != `"b"` || resps[1].Error == nil || resps[1].Error.Code != CodeNotFound {
+		t.Errorf("second response of the batch = %+v, want code %d", resps[1], CodeNotFound)
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET: got status %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestBasicAuth(t *testing.T) {
+	chain := newTestChain(t)
+	_, url := newTestClient(t, chain.BlockChain, WithBasicAuth("user", "secret"))
+
+	for _, c := range []struct {
+		user, password string
+		ok             bool
+	}{
+		{"", "", false},
+		{"user", "wrong", false},
+		{"other", "secret", false},
+		{"user", "secret", true},
+	} {
+		var height int
+		err := NewClient(url, WithCredentials(c.user, c.password)).Call(MethodGetBlockCount, &height)
+		if (err == nil) != c.ok {
+			t.Errorf("credentials %q:%q: got %v, want success %v", c.user, c.password, err, c.ok)
+		}
+	}
+}
+
+func TestLoopbackOnly(t *testing.T) {
+	chain := newTestChain(t)
+	body := `{"jsonrpc":"2.0","method":"getblockcount","id":1}`
+
+	// without credentials only loopback clients are answered
+	for _, c := range []struct {
+		remoteAddr string
+		opts       []ServerOpt
+		status     int
+	}{
+		{"127.0.0.1:40000", nil, http.StatusOK},
+		{"[::1]:40000", nil, http.StatusOK},
+		{"192.0.2.1:40000", nil, http.StatusUnauthorized},
+		{"192.0.2.1:40000", []ServerOpt{WithBasicAuth("user", "secret")}, http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.RemoteAddr = c.remoteAddr
+		req.SetBasicAuth("user", "secret")
+		w := httptest.NewRecorder()
+		NewServer(chain.BlockChain, c.opts...).ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("request from %s: got status %d, want %d", c.remoteAddr, w.Code, c.status)
+		}
+	}
+
+	// nor does it listen on other addresses
+	ln, err := net.Listen("tcp", "0.0.0.0:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = NewServer(chain.BlockChain).Serve(context.Background(), ln)
+	if !errors.Is(err, ErrorNoCredentials) {
+		t.Errorf("Serve on %s: got %v, want %v", ln.Addr(), err, ErrorNoCredentials)
+	}
+
+	ln, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- NewServer(chain.BlockChain).Serve(ctx, ln) }()
+
+	var height int
+	if err := NewClient(ln.Addr().String()).Call(MethodGetBlockCount, &height); err != nil || height != 1 {
+		t.Errorf("getblockcount on %s = %d, %v, want 1", ln.Addr(), height, err)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Serve: %v", err)
+	}
+}
@@ -0,0 +1,257 @@
+package stratum
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"blockchain/pkg/consensus"
+	"blockchain/pkg/consensus/pow"
+)
+
+var ErrorClientClosed = errors.New("stratum connection closed")
+
+// Client is a minimal single threaded Stratum miner. It stands in for real
+// mining hardware when running a pool locally.
+type Client struct {
+	conn   net.Conn
+	worker string
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[uint64]chan *message
+
+	// jobs holds the latest job only, older ones are replaced
+	jobs       chan *Job
+	difficulty atomic.Int64
+	closed     chan struct{}
+
+	accepted atomic.Int64
+	rejected atomic.Int64
+}
+
+// Dial connects to a pool, subscribes and authorizes worker
+func Dial(addr, worker string) (*Client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		conn:    conn,
+		worker:  worker,
+		pending: make(map[uint64]chan *message),
+		jobs:    make(chan *Job, 1),
+		closed:  make(chan struct{}),
+	}
+	go c.readLoop()
+
+	if _, err := c.call(MethodSubscribe, []string{}); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("error while subscribing: %w", err)
+	}
+	if _, err := c.call(MethodAuthorize, []string{worker, ""}); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("error while authorizing %s: %w", worker, err)
+	}
+
+	return c, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Stats returns the number of shares the pool accepted and rejected
+func (c *Client) Stats() (accepted, rejected int) {
+	return int(c.accepted.Load()), int(c.rejected.Load())
+}
+
+// Mine works on the jobs of the pool, submitting every share found, until
+// ctx is done or the connection is closed
+func (c *Client) Mine(ctx context.Context) error {
+	var j *Job
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.closed:
+		return ErrorClientClosed
+	case j = <-c.jobs:
+	}
+
+	for {
+		next, err := c.mineJob(ctx, j)
+		if err != nil {
+			return err
+		}
+		j = next
+	}
+}
+
+// mineJob searches shares for j until a new job arrives and returns it
+func (c *Client) mineJob(ctx context.Context, j *Job) (*Job, error) {
+	alg, err := pow.ParseAlgorithm(j.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	header, err := j.header()
+	if err != nil {
+		return nil, err
+	}
+
+	target := pow.Target(int(c.difficulty.Load()))
+	var hash big.Int
+	for {
+		for nonce := 0; nonce < math.MaxUint32; nonce++ {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-c.closed:
+				return nil, ErrorClientClosed
+			case next := <-c.jobs:
+				return next, nil
+			default:
+			}
+
+			header.Nonce = nonce
+			hash.SetBytes(alg.Hash(header.SealData()))
+			if hash.Cmp(target) >= 0 {
+				continue
+			}
+
+			if err := c.submit(j.ID, header.Timestamp, nonce); err != nil {
+				if !errors.As(err, new(*Error)) {
+					return nil, err
+				}
+				c.rejected.Add(1)
+				continue
+			}
+			c.accepted.Add(1)
+		}
+		header.Timestamp++
+	}
+}
+
+func (c *Client) submit(jobID string, ntime int64, nonce int) error {
+	_, err := c.call(MethodSubmit, []string{
+		c.worker, jobID, "", formatHex(uint64(ntime)), formatHex(uint64(nonce)),
+	})
+
+	return err
+}
+
+// call sends a request and waits for its response
+func (c *Client) call(method string, params interface{}) (json.RawMessage, error) {
+	c.mu.Lock()
+	c.nextID++
+	id := c.nextID
+	ch := make(chan *message, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	if err := c.send(&request{ID: &id, Method: method, Params: params}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-c.closed:
+		return nil, ErrorClientClosed
+	case resp := <-ch:
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+		return resp.Result, nil
+	}
+}
+
+func (c *Client) send(msg interface{}) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	_, err = c.conn.Write(append(data, '\n'))
+
+	return err
+}
+
+func (c *Client) readLoop() {
+	defer close(c.closed)
+
+	scanner := bufio.NewScanner(c.conn)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineSize)
+	for scanner.Scan() {
+		msg := &message{}
+		if err := json.Unmarshal(scanner.Bytes(), msg); err != nil {
+			return
+		}
+
+		switch msg.Method {
+		case "":
+			if msg.ID == nil {
+				continue
+			}
+			c.mu.Lock()
+			ch, ok := c.pending[*msg.ID]
+			delete(c.pending, *msg.ID)
+			c.mu.Unlock()
+			if ok {
+				ch <- msg
+			}
+		case MethodSetDifficulty:
+			var difficulty int
+			if len(msg.Params) == 1 && json.Unmarshal(msg.Params[0], &difficulty) == nil {
+				c.difficulty.Store(int64(difficulty))
+			}
+		case MethodNotify:
+			j, err := parseJob(msg.Params)
+			if err != nil {
+				continue
+			}
+			// drop the job not started yet, the new one replaces it
+			select {
+			case <-c.jobs:
+			default:
+			}
+			c.jobs <- j
+		}
+	}
+}
+
+func (j *Job) header() (*consensus.Header, error) {
+	h := &consensus.Header{
+		Timestamp:  j.Time,
+		Height:     j.Height,
+		Difficulty: j.Difficulty,
+	}
+
+	for _, f := range []struct {
+		dst *[]byte
+		hex string
+	}{
+		{&h.PrevHash, j.PrevHash},
+		{&h.TxRoot, j.TxRoot},
+		{&h.WitnessRoot, j.WitnessRoot},
+	} {
+		b, err := hex.DecodeString(f.hex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid job %s: %w", j.ID, err)
+		}
+		*f.dst = b
+	}
+
+	return h, nil
+}
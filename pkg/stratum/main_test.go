@@ -0,0 +1,29 @@
+package stratum
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+// TestMain runs the tests in a scratch directory, the wallet package
+// creates its directory relative to the working directory
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "stratum-test")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	code := func() int {
+		defer os.RemoveAll(dir)
+
+		if err := os.Chdir(dir); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		return m.Run()
+	}()
+	os.Exit(code)
+}
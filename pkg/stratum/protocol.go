@@ -0,0 +1,159 @@
+// Package stratum runs a mining pool speaking Stratum v1, line delimited
+// JSON-RPC over TCP.
+//
+// The methods are the usual ones: mining.subscribe, mining.authorize,
+// mining.set_difficulty, mining.notify and mining.submit. Headers of this
+// chain are not Bitcoin headers though, so jobs carry its header fields:
+//
+//	mining.notify [job_id, prev_hash, tx_root, witness_root, height, difficulty, ntime, algorithm, clean_jobs]
+//	mining.submit [worker, job_id, extranonce2, ntime, nonce]
+//
+// Hashes are hex, ntime and nonce are hex encoded integers and difficulties
+// count leading zero bits. The pool gives every subscription its own
+// coinbase, the roots in its jobs already commit to it, so extranonce2 is
+// always empty and miners roll ntime once they run out of nonces.
+package stratum
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+const (
+	MethodSubscribe     = "mining.subscribe"
+	MethodAuthorize     = "mining.authorize"
+	MethodSubmit        = "mining.submit"
+	MethodNotify        = "mining.notify"
+	MethodSetDifficulty = "mining.set_difficulty"
+)
+
+// extraNonce2Size is always 0, the pool rolls the coinbase itself
+const extraNonce2Size = 0
+
+// Error codes of Stratum v1
+const (
+	CodeOther         = 20
+	CodeJobNotFound   = 21
+	CodeDuplicate     = 22
+	CodeLowDifficulty = 23
+	CodeUnauthorized  = 24
+	CodeNotSubscribed = 25
+)
+
+// Error is the [code, message, traceback] error of a Stratum response
+type Error struct {
+	Code    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("stratum error %d: %s", e.Code, e.Message)
+}
+
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{e.Code, e.Message, nil})
+}
+
+func (e *Error) UnmarshalJSON(data []byte) error {
+	var fields []json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if len(fields) < 2 {
+		return fmt.Errorf("invalid stratum error: %s", data)
+	}
+	if err := json.Unmarshal(fields[0], &e.Code); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(fields[1], &e.Message)
+}
+
+// message is any line of the protocol, requests and notifications have a
+// Method, responses a Result or an Error
+type message struct {
+	ID     *uint64           `json:"id"`
+	Method string            `json:"method,omitempty"`
+	Params []json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage   `json:"result,omitempty"`
+	Error  *Error            `json:"error,omitempty"`
+}
+
+// request is a request or notification being sent
+type request struct {
+	ID     *uint64     `json:"id"`
+	Method string      `json:"method"`
+	Params interface{} `json:"params"`
+}
+
+// response answers a request, Error is null on success
+type response struct {
+	ID     *uint64     `json:"id"`
+	Result interface{} `json:"result"`
+	Error  *Error      `json:"error"`
+}
+
+// Job is the work of a mining.notify
+type Job struct {
+	ID          string
+	PrevHash    string
+	TxRoot      string
+	WitnessRoot string
+	Height      int
+	Difficulty  int
+	Time        int64
+	Algorithm   string
+	Clean       bool
+}
+
+func (j *Job) params() []interface{} {
+	return []interface{}{
+		j.ID, j.PrevHash, j.TxRoot, j.WitnessRoot, j.Height, j.Difficulty,
+		formatHex(uint64(j.Time)), j.Algorithm, j.Clean,
+	}
+}
+
+func parseJob(params []json.RawMessage) (*Job, error) {
+	if len(params) != 9 {
+		return nil, fmt.Errorf("mining.notify takes 9 params, got %d", len(params))
+	}
+
+	j := &Job{}
+	var ntime string
+	for i, dst := range []interface{}{
+		&j.ID, &j.PrevHash, &j.TxRoot, &j.WitnessRoot, &j.Height, &j.Difficulty,
+		&ntime, &j.Algorithm, &j.Clean,
+	} {
+		if err := json.Unmarshal(params[i], dst); err != nil {
+			return nil, fmt.Errorf("invalid mining.notify param %d: %w", i, err)
+		}
+	}
+
+	t, err := parseHex(ntime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mining.notify ntime: %w", err)
+	}
+	j.Time = int64(t)
+
+	return j, nil
+}
+
+func formatHex(v uint64) string {
+	return strconv.FormatUint(v, 16)
+}
+
+func parseHex(s string) (uint64, error) {
+	return strconv.ParseUint(s, 16, 63)
+}
+
+func parseStrings(params []json.RawMessage) ([]string, error) {
+	strs := make([]string, len(params))
+	for i, p := range params {
+		if err := json.Unmarshal(p, &strs[i]); err != nil {
+			return nil, fmt.Errorf("param %d is not a string", i)
+		}
+	}
+
+	return strs, nil
+}
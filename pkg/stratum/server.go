@@ -0,0 +1,611 @@
+package stratum
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"blockchain/pkg/blockchain"
+	"blockchain/pkg/consensus/pow"
+	"blockchain/pkg/wallet"
+)
+
+const (
+	// DefaultShareDifficulty is the difficulty shares must meet, in leading zero bits
+	DefaultShareDifficulty = 4
+	// DefaultRefreshInterval is how often jobs are rebuilt from the tip and the pool
+	DefaultRefreshInterval = 30 * time.Second
+
+	// maxTimeDrift bounds how far ahead of the clock miners may roll ntime
+	maxTimeDrift = 2 * 60 * 60
+	maxLineSize  = 64 * 1024
+	// maxJobs is the number of jobs of a session shares are still accepted for
+	maxJobs = 8
+)
+
+// WorkSource hands out block templates and takes the blocks solved from
+// them, *miner.Miner is one. The pool subscribes to its chain events to
+// send new jobs as soon as the tip changes.
+type WorkSource interface {
+	GetBlockTemplate() (*blockchain.BlockTemplate, error)
+	SubmitBlock(block *blockchain.Block) error
+	Subscribe(opts ...blockchain.SubscribeOpt) *blockchain.Subscription
+}
+
+// WorkerStats are the contributions of a worker since the pool started
+type WorkerStats struct {
+	Accepted int
+	Rejected int
+	Blocks   int
+}
+
+// Server is a Stratum mining pool. Workers authorize as "address" or
+// "address.name", shares they find since the last block are paid out by
+// the coinbase of the jobs handed out, in proportion to their number.
+type Server struct {
+	source   WorkSource
+	poolAddr string
+
+	shareDifficulty int
+	refresh         time.Duration
+
+	mu       sync.Mutex
+	sessions map[*session]struct{}
+	template *blockchain.BlockTemplate
+	// round counts the shares of every payout address since the last block
+	round       map[string]int
+	workers     map[string]*WorkerStats
+	nextJob     uint64
+	nextSession uint32
+}
+
+type ServerOpt func(*Server)
+
+// WithShareDifficulty sets the difficulty of shares, it is capped at the block difficulty
+func WithShareDifficulty(difficulty int) ServerOpt {
+	return func(s *Server) {
+		if difficulty > 0 {
+			s.shareDifficulty = difficulty
+		}
+	}
+}
+
+// WithRefreshInterval sets how often new jobs are sent without a new block,
+// picking up the transactions that arrived since
+func WithRefreshInterval(refresh time.Duration) ServerOpt {
+	return func(s *Server) {
+		if refresh > 0 {
+			s.refresh = refresh
+		}
+	}
+}
+
+// NewServer creates a pool mining on source. poolAddr is paid while no
+// worker has found a share yet.
+func NewServer(source WorkSource, poolAddr string, opts ...ServerOpt) (*Server, error) {
+	if _, _, err := wallet.DecodeAddress(poolAddr); err != nil {
+		return nil, fmt.Errorf("invalid pool address %s: %w", poolAddr, err)
+	}
+
+	s := &Server{
+		source:          source,
+		poolAddr:        poolAddr,
+		shareDifficulty: DefaultShareDifficulty,
+		refresh:         DefaultRefreshInterval,
+		sessions:        make(map[*session]struct{}),
+		round:           make(map[string]int),
+		workers:         make(map[string]*WorkerStats),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s, nil
+}
+
+// ListenAndServe accepts miners on addr until ctx is done
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(ctx, ln)
+}
+
+// Serve accepts miners on ln until ctx is done, ln is closed on return
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// subscribe first, a block connected while the first template is built
+	// still refreshes it
+	sub := s.source.Subscribe(
+		blockchain.WithEventTypes(blockchain.EventBlockConnected),
+		blockchain.WithBackpressure(blockchain.BackpressureDrop),
+	)
+	if err := s.updateTemplate(); err != nil {
+		sub.Unsubscribe()
+		ln.Close()
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+	go s.refreshLoop(ctx, sub)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		sess := s.newSession(conn)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sess.serve(ctx)
+		}()
+	}
+}
+
+// Stats returns the contributions of every worker
+func (s *Server) Stats() map[string]WorkerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make(map[string]WorkerStats, len(s.workers))
+	for name, w := range s.workers {
+		stats[name] = *w
+	}
+
+	return stats
+}
+
+// Round returns the shares of every payout address since the last block
+func (s *Server) Round() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	round := make(map[string]int, len(s.round))
+	for addr, n := range s.round {
+		round[addr] = n
+	}
+
+	return round
+}
+
+func (s *Server) newSession(conn net.Conn) *session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextSession++
+	extraNonce1 := make([]byte, 4)
+	binary.BigEndian.PutUint32(extraNonce1, s.nextSession)
+
+	sess := &session{
+		server:      s,
+		conn:        conn,
+		extraNonce1: hex.EncodeToString(extraNonce1),
+		workers:     make(map[string]string),
+		jobs:        make(map[string]*job),
+	}
+	s.sessions[sess] = struct{}{}
+
+	return sess
+}
+
+func (s *Server) removeSession(sess *session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, sess)
+}
+
+// refreshLoop rebuilds the jobs of every session once a block is connected,
+// they are sent as clean jobs since the old tip is stale, and every refresh
+// interval otherwise
+func (s *Server) refreshLoop(ctx context.Context, sub *blockchain.Subscription) {
+	defer sub.Unsubscribe()
+
+	ticker := time.NewTicker(s.refresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-sub.Events():
+			// events missed for lack of room need no catching up, the
+			// template is built from the tip
+		}
+
+		if err := s.updateTemplate(); err != nil {
+			continue
+		}
+		s.broadcastJobs()
+	}
+}
+
+func (s *Server) updateTemplate() error {
+	tmpl, err := s.source.GetBlockTemplate()
+	if err != nil {
+		return fmt.Errorf("error while getting block template: %w", err)
+	}
+
+	s.mu.Lock()
+	s.template = tmpl
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *Server) broadcastJobs() {
+	s.mu.Lock()
+	sessions := make([]*session, 0, len(s.sessions))
+	for sess := range s.sessions {
+		sessions = append(sessions, sess)
+	}
+	s.mu.Unlock()
+
+	for _, sess := range sessions {
+		if sess.ready() {
+			_ = sess.sendJob()
+		}
+	}
+}
+
+// newJob builds the job of a session from the current template, with a
+// coinbase of its own paying out the shares of the round
+func (s *Server) newJob(extraNonce1 string) (*job, error) {
+	s.mu.Lock()
+	tmpl := s.template
+	s.nextJob++
+	id := formatHex(s.nextJob)
+	shares := make(map[string]int, len(s.round))
+	for addr, n := range s.round {
+		shares[addr] = n
+	}
+	s.mu.Unlock()
+
+	if len(shares) == 0 {
+		shares[s.poolAddr] = 1
+	}
+	coinbase, err := blockchain.CoinbaseTxShares(extraNonce1+id, shares)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err = tmpl.WithCoinbase(coinbase)
+	if err != nil {
+		return nil, err
+	}
+
+	shareDifficulty := s.shareDifficulty
+	if shareDifficulty > tmpl.Header.Difficulty {
+		shareDifficulty = tmpl.Header.Difficulty
+	}
+
+	return &job{
+		id:              id,
+		template:        tmpl,
+		shareDifficulty: shareDifficulty,
+		submitted:       make(map[string]struct{}),
+	}, nil
+}
+
+// submitShare checks a share of worker, paid to payoutAddr, and submits
+// the block when the share also meets the block target
+func (s *Server) submitShare(worker, payoutAddr string, j *job, ntime int64, nonce int) error {
+	h := j.template.Header
+	h.Timestamp = ntime
+	h.Nonce = nonce
+
+	var hash big.Int
+	hash.SetBytes(j.template.Algorithm.Hash(h.SealData()))
+	if hash.Cmp(pow.Target(j.shareDifficulty)) >= 0 {
+		s.reject(worker)
+		return &Error{Code: CodeLowDifficulty, Message: "low difficulty share"}
+	}
+
+	s.mu.Lock()
+	stats := s.workerStats(worker)
+	stats.Accepted++
+	s.round[payoutAddr]++
+	s.mu.Unlock()
+
+	if hash.Cmp(new(big.Int).SetBytes(j.template.Target)) >= 0 {
+		return nil
+	}
+
+	// the share solves the block. The round ends before submitting, the jobs
+	// sent once the block is connected must not pay it again.
+	s.mu.Lock()
+	round := s.round
+	s.round = make(map[string]int)
+	s.mu.Unlock()
+
+	if err := s.source.SubmitBlock(j.template.Solve(ntime, nonce)); err != nil {
+		// a failed submission still counts as a share, the round goes on
+		s.mu.Lock()
+		for addr, n := range round {
+			s.round[addr] += n
+		}
+		s.mu.Unlock()
+		return nil
+	}
+
+	s.mu.Lock()
+	stats.Blocks++
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *Server) reject(worker string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.workerStats(worker).Rejected++
+}
+
+func (s *Server) workerStats(worker string) *WorkerStats {
+	stats, ok := s.workers[worker]
+	if !ok {
+		stats = &WorkerStats{}
+		s.workers[worker] = stats
+	}
+
+	return stats
+}
+
+// job is the work sent to a session by one mining.notify
+type job struct {
+	id              string
+	template        *blockchain.BlockTemplate
+	shareDifficulty int
+	// submitted holds the ntime and nonce of the shares found for the job
+	submitted map[string]struct{}
+}
+
+// session is the connection of one miner, which may run several workers
+type session struct {
+	server      *Server
+	conn        net.Conn
+	extraNonce1 string
+
+	writeMu sync.Mutex
+
+	mu         sync.Mutex
+	subscribed bool
+	// workers maps authorized worker names to their payout address
+	workers map[string]string
+	jobs    map[string]*job
+	// jobIDs lists the ids of jobs, oldest first
+	jobIDs []string
+	// difficulty is the share difficulty last sent to the miner
+	difficulty int
+}
+
+func (sess *session) serve(ctx context.Context) {
+	defer sess.server.removeSession(sess)
+	defer sess.conn.Close()
+
+	go func() {
+		<-ctx.Done()
+		sess.conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(sess.conn)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineSize)
+	for scanner.Scan() {
+		msg := &message{}
+		if err := json.Unmarshal(scanner.Bytes(), msg); err != nil {
+			return
+		}
+		if msg.Method == "" {
+			continue
+		}
+
+		result, err := sess.handle(msg)
+		var stratumErr *Error
+		if err != nil && !errors.As(err, &stratumErr) {
+			stratumErr = &Error{Code: CodeOther, Message: err.Error()}
+		}
+		if msg.ID == nil {
+			continue
+		}
+		if err := sess.send(&response{ID: msg.ID, Result: result, Error: stratumErr}); err != nil {
+			return
+		}
+
+		if msg.Method == MethodAuthorize && err == nil && sess.ready() {
+			if err := sess.sendJob(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (sess *session) handle(msg *message) (interface{}, error) {
+	switch msg.Method {
+	case MethodSubscribe:
+		sess.mu.Lock()
+		sess.subscribed = true
+		sess.mu.Unlock()
+
+		subscriptions := [][]string{
+			{MethodSetDifficulty, sess.extraNonce1},
+			{MethodNotify, sess.extraNonce1},
+		}
+		return []interface{}{subscriptions, sess.extraNonce1, extraNonce2Size}, nil
+	case MethodAuthorize:
+		return sess.authorize(msg.Params)
+	case MethodSubmit:
+		return sess.submit(msg.Params)
+	default:
+		return nil, &Error{Code: CodeOther, Message: "unknown method " + msg.Method}
+	}
+}
+
+func (sess *session) authorize(params []json.RawMessage) (interface{}, error) {
+	args, err := parseStrings(params)
+	if err != nil || len(args) < 1 {
+		return false, &Error{Code: CodeOther, Message: "mining.authorize takes a worker name"}
+	}
+
+	worker := args[0]
+	payoutAddr := strings.SplitN(worker, ".", 2)[0]
+	if _, _, err := wallet.DecodeAddress(payoutAddr); err != nil {
+		return false, &Error{Code: CodeUnauthorized, Message: "worker name must start with a payout address"}
+	}
+
+	sess.mu.Lock()
+	sess.workers[worker] = payoutAddr
+	sess.mu.Unlock()
+
+	return true, nil
+}
+
+func (sess *session) submit(params []json.RawMessage) (interface{}, error) {
+	args, err := parseStrings(params)
+	if err != nil || len(args) != 5 {
+		return false, &Error{Code: CodeOther, Message: "mining.submit takes 5 string params"}
+	}
+	worker, jobID, ntimeHex, nonceHex := args[0], args[1], args[3], args[4]
+
+	sess.mu.Lock()
+	subscribed := sess.subscribed
+	payoutAddr, authorized := sess.workers[worker]
+	j, ok := sess.jobs[jobID]
+	sess.mu.Unlock()
+
+	if !subscribed {
+		return false, &Error{Code: CodeNotSubscribed, Message: "not subscribed"}
+	}
+	if !authorized {
+		return false, &Error{Code: CodeUnauthorized, Message: "unauthorized worker"}
+	}
+	if !ok {
+		sess.server.reject(worker)
+		return false, &Error{Code: CodeJobNotFound, Message: "job not found"}
+	}
+
+	ntime, err := parseHex(ntimeHex)
+	if err != nil || int64(ntime) < j.template.Header.Timestamp || int64(ntime) > time.Now().Unix()+maxTimeDrift {
+		sess.server.reject(worker)
+		return false, &Error{Code: CodeOther, Message: "ntime out of range"}
+	}
+	nonce, err := parseHex(nonceHex)
+	if err != nil {
+		sess.server.reject(worker)
+		return false, &Error{Code: CodeOther, Message: "invalid nonce"}
+	}
+
+	// keyed by the parsed values, the same share can be written in several ways
+	key := fmt.Sprintf("%d:%d", ntime, nonce)
+	sess.mu.Lock()
+	_, dup := j.submitted[key]
+	j.submitted[key] = struct{}{}
+	sess.mu.Unlock()
+	if dup {
+		sess.server.reject(worker)
+		return false, &Error{Code: CodeDuplicate, Message: "duplicate share"}
+	}
+
+	if err := sess.server.submitShare(worker, payoutAddr, j, int64(ntime), int(nonce)); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ready reports whether the session can be sent jobs
+func (sess *session) ready() bool {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	return sess.subscribed && len(sess.workers) > 0
+}
+
+// sendJob sends a new job, and the share difficulty when it changed. Jobs
+// on another tip than the previous one are sent as clean jobs.
+func (sess *session) sendJob() error {
+	j, err := sess.server.newJob(sess.extraNonce1)
+	if err != nil {
+		return err
+	}
+	h := j.template.Header
+
+	sess.mu.Lock()
+	clean := true
+	for _, prev := range sess.jobs {
+		clean = string(prev.template.Header.PrevHash) != string(h.PrevHash)
+		break
+	}
+	if clean {
+		sess.jobs = make(map[string]*job)
+		sess.jobIDs = nil
+	}
+	sess.jobs[j.id] = j
+	sess.jobIDs = append(sess.jobIDs, j.id)
+	if len(sess.jobIDs) > maxJobs {
+		delete(sess.jobs, sess.jobIDs[0])
+		sess.jobIDs = sess.jobIDs[1:]
+	}
+	sendDifficulty := sess.difficulty != j.shareDifficulty
+	sess.difficulty = j.shareDifficulty
+	sess.mu.Unlock()
+
+	if sendDifficulty {
+		if err := sess.send(&request{Method: MethodSetDifficulty, Params: []int{j.shareDifficulty}}); err != nil {
+			return err
+		}
+	}
+
+	notify := &Job{
+		ID:          j.id,
+		PrevHash:    hex.EncodeToString(h.PrevHash),
+		TxRoot:      hex.EncodeToString(h.TxRoot),
+		WitnessRoot: hex.EncodeToString(h.WitnessRoot),
+		Height:      h.Height,
+		Difficulty:  h.Difficulty,
+		Time:        h.Timestamp,
+		Algorithm:   j.template.Algorithm.String(),
+		Clean:       clean,
+	}
+
+	return sess.send(&request{Method: MethodNotify, Params: notify.params()})
+}
+
+func (sess *session) send(msg interface{}) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	sess.writeMu.Lock()
+	defer sess.writeMu.Unlock()
+
+	_, err = sess.conn.Write(append(data, '\n'))
+
+	return err
+}
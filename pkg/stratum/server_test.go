@@ -0,0 +1,391 @@
+package stratum
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"blockchain/pkg/blockchain"
+	"blockchain/pkg/consensus/pow"
+	"blockchain/pkg/crypto"
+	"blockchain/pkg/wallet"
+)
+
+// chainSource hands out templates of a chain paying addr, like a miner
+type chainSource struct {
+	chain *blockchain.BlockChain
+	addr  string
+}
+
+func (cs *chainSource) GetBlockTemplate() (*blockchain.BlockTemplate, error) {
+	cbTx, err := blockchain.CoinbaseTx(cs.addr, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return cs.chain.NewBlockTemplate(cbTx, nil)
+}
+
+func (cs *chainSource) SubmitBlock(block *blockchain.Block) error {
+	return cs.chain.SubmitBlock(block)
+}
+
+func (cs *chainSource) Subscribe(opts ...blockchain.SubscribeOpt) *blockchain.Subscription {
+	return cs.chain.Subscribe(opts...)
+}
+
+func newTestAddress(t *testing.T) string {
+	t.Helper()
+
+	w, err := wallet.NewWallet(crypto.KeyECDSA)
+	if err != nil {
+		t.Fatalf("NewWallet: %v", err)
+	}
+
+	return string(w.Address())
+}
+
+// newTestPool starts a pool on a new chain paying poolAddr and returns the
+// chain and the address the pool listens on
+func newTestPool(t *testing.T, poolAddr string, opts ...ServerOpt) (*Server, *blockchain.BlockChain, string) {
+	t.Helper()
+
+	chain, err := blockchain.InitBlockChain(poolAddr, blockchain.WithDataDir(t.TempDir()))
+	if err != nil {
+		t.Fatalf("InitBlockChain: %v", err)
+	}
+	t.Cleanup(chain.Close)
+
+	srv, err := NewServer(&chainSource{chain: chain, addr: poolAddr}, poolAddr, opts...)
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(ctx, ln) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Errorf("Serve: %v", err)
+		}
+	})
+
+	return srv, chain, ln.Addr().String()
+}
+
+// testMiner speaks the protocol line by line, keeping the notifications
+// read while waiting for responses
+type testMiner struct {
+	t       *testing.T
+	conn    net.Conn
+	scanner *bufio.Scanner
+	nextID  uint64
+	jobs    []*Job
+}
+
+func dialTestMiner(t *testing.T, addr string) *testMiner {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &testMiner{t: t, conn: conn, scanner: bufio.NewScanner(conn)}
+}
+
+func (m *testMiner) read() *message {
+	m.t.Helper()
+
+	m.conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	if !m.scanner.Scan() {
+		m.t.Fatalf("connection closed: %v", m.scanner.Err())
+	}
+	msg := &message{}
+	if err := json.Unmarshal(m.scanner.Bytes(), msg); err != nil {
+		m.t.Fatalf("invalid message %s: %v", m.scanner.Bytes(), err)
+	}
+	if msg.Method == MethodNotify {
+		j, err := parseJob(msg.Params)
+		if err != nil {
+			m.t.Fatalf("parseJob: %v", err)
+		}
+		m.jobs = append(m.jobs, j)
+	}
+
+	return msg
+}
+
+// call sends a request and returns the error of its response
+func (m *testMiner) call(method string, params ...string) *Error {
+	m.t.Helper()
+
+	m.nextID++
+	id := m.nextID
+	data, err := json.Marshal(&request{ID: &id, Method: method, Params: params})
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	if _, err := m.conn.Write(append(data, '\n')); err != nil {
+		m.t.Fatal(err)
+	}
+
+	for {
+		msg := m.read()
+		if msg.Method == "" && msg.ID != nil && *msg.ID == id {
+			return msg.Error
+		}
+	}
+}
+
+func (m *testMiner) mustCall(method string, params ...string) {
+	m.t.Helper()
+
+	if err := m.call(method, params...); err != nil {
+		m.t.Fatalf("%s: %v", method, err)
+	}
+}
+
+func (m *testMiner) expectError(code int, method string, params ...string) {
+	m.t.Helper()
+
+	if err := m.call(method, params...); err == nil || err.Code != code {
+		m.t.Fatalf("%s: got error %v, want code %d", method, err, code)
+	}
+}
+
+// nextJob returns the first job notified after the ones already returned
+func (m *testMiner) nextJob(seen int) *Job {
+	m.t.Helper()
+
+	for len(m.jobs) <= seen {
+		m.read()
+	}
+
+	return m.jobs[seen]
+}
+
+func (m *testMiner) submit(worker string, j *Job, nonce int) *Error {
+	m.t.Helper()
+
+	return m.call(MethodSubmit, worker, j.ID, "", formatHex(uint64(j.Time)), formatHex(uint64(nonce)))
+}
+
+// findNonce returns the first nonce from start whose hash is below
+// shareTarget, and below blockTarget exactly when solves is set
+func findNonce(t *testing.T, j *Job, start, shareDifficulty int, solves bool) int {
+	t.Helper()
+
+	alg, err := pow.ParseAlgorithm(j.Algorithm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := j.header()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var hash big.Int
+	for nonce := start; ; nonce++ {
+		header.Nonce = nonce
+		hash.SetBytes(alg.Hash(header.SealData()))
+		if hash.Cmp(pow.Target(shareDifficulty)) >= 0 {
+			continue
+		}
+		if (hash.Cmp(pow.Target(j.Difficulty)) < 0) == solves {
+			return nonce
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	const shareDifficulty = 4
+	poolAddr, alice, bob := newTestAddress(t), newTestAddress(t), newTestAddress(t)
+	rig := alice + ".rig1"
+
+	// a refresh interval longer than the test, new jobs come from
+	// authorizing or from a connected block
+	srv, chain, addr := newTestPool(t, poolAddr, WithShareDifficulty(shareDifficulty), WithRefreshInterval(time.Hour))
+	m := dialTestMiner(t, addr)
+
+	m.expectError(CodeNotSubscribed, MethodSubmit, rig, "1", "", "0", "0")
+	m.mustCall(MethodSubscribe)
+	m.expectError(CodeUnauthorized, MethodAuthorize, "not an address", "")
+	m.expectError(CodeUnauthorized, MethodSubmit, rig, "1", "", "0", "0")
+
+	m.mustCall(MethodAuthorize, rig, "")
+	m.mustCall(MethodAuthorize, bob, "")
+	j := m.nextJob(1)
+
+	// shares not meeting the share difficulty or for unknown jobs are rejected
+	m.expectError(CodeJobNotFound, MethodSubmit, rig, "ffff", "", formatHex(uint64(j.Time)), "0")
+	low := 0
+	for low == findNonce(t, j, low, shareDifficulty, false) {
+		low++
+	}
+	if err := m.submit(rig, j, low); err == nil || err.Code != CodeLowDifficulty {
+		t.Fatalf("low difficulty share: got %v, want code %d", err, CodeLowDifficulty)
+	}
+
+	// alice finds three shares, bob one and submits it twice
+	nonce := 0
+	for _, worker := range []string{rig, rig, rig, bob} {
+		nonce = findNonce(t, j, nonce, shareDifficulty, false)
+		if err := m.submit(worker, j, nonce); err != nil {
+			t.Fatalf("share of %s: %v", worker, err)
+		}
+		nonce++
+	}
+	if err := m.submit(bob, j, nonce-1); err == nil || err.Code != CodeDuplicate {
+		t.Fatalf("duplicate share: got %v, want code %d", err, CodeDuplicate)
+	}
+	// the same share written with a leading zero or in uppercase
+	ntimeHex, nonceHex := formatHex(uint64(j.Time)), formatHex(uint64(nonce-1))
+	for _, args := range [][2]string{
+		{"0" + ntimeHex, nonceHex},
+		{ntimeHex, "0" + nonceHex},
+		{strings.ToUpper(ntimeHex), strings.ToUpper(nonceHex)},
+	} {
+		if err := m.call(MethodSubmit, bob, j.ID, "", args[0], args[1]); err == nil || err.Code != CodeDuplicate {
+			t.Fatalf("duplicate share %s:%s: got %v, want code %d", args[0], args[1], err, CodeDuplicate)
+		}
+	}
+
+	round := srv.Round()
+	if round[alice] != 3 || round[bob] != 1 || len(round) != 2 {
+		t.Fatalf("round = %v, want 3 shares of alice and 1 of bob", round)
+	}
+
+	// the coinbase of a new job pays the round out in proportion
+	m.mustCall(MethodAuthorize, rig, "")
+	j = m.nextJob(2)
+	if j.Clean {
+		t.Error("job on the same tip is clean")
+	}
+	nonce = findNonce(t, j, 0, shareDifficulty, true)
+	if err := m.submit(bob, j, nonce); err != nil {
+		t.Fatalf("block solving share: %v", err)
+	}
+
+	tip, err := chain.GetBlock(chain.LastHash())
+	if err != nil {
+		t.Fatalf("GetBlock: %v", err)
+	}
+	if tip.Height != 1 {
+		t.Fatalf("tip height = %d, want 1", tip.Height)
+	}
+	paid := make(map[string]int)
+	for _, out := range tip.Transactions[len(tip.Transactions)-1].Outputs {
+		paid[hex.EncodeToString(out.PubKeyHash)] += out.Value
+	}
+	for _, tc := range []struct {
+		address string
+		want    int
+	}{
+		{alice, 15},
+		{bob, 5},
+		{poolAddr, 0},
+	} {
+		pubKeyHash, err := wallet.PubKeyHashFromAddress(tc.address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := paid[hex.EncodeToString(pubKeyHash)]; got != tc.want {
+			t.Errorf("coinbase pays %d to %s, want %d", got, tc.address, tc.want)
+		}
+	}
+
+	// the connected block ends the round and sends a clean job on top of it
+	j = m.nextJob(3)
+	if !j.Clean || j.PrevHash != hex.EncodeToString(tip.Hash) {
+		t.Errorf("job after the block is clean %v on %s, want clean on %x", j.Clean, j.PrevHash, tip.Hash)
+	}
+	if round := srv.Round(); len(round) != 0 {
+		t.Errorf("round after the block = %v, want none", round)
+	}
+
+	stats := srv.Stats()
+	for worker, want := range map[string]WorkerStats{
+		rig: {Accepted: 3, Rejected: 2},
+		bob: {Accepted: 2, Rejected: 4, Blocks: 1},
+	} {
+		if got := stats[worker]; got != want {
+			t.Errorf("stats of %s = %+v, want %+v", worker, got, want)
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	poolAddr, alice, bob := newTestAddress(t), newTestAddress(t), newTestAddress(t)
+	srv, chain, addr := newTestPool(t, poolAddr)
+
+	sub := chain.Subscribe(blockchain.WithEventTypes(blockchain.EventBlockConnected))
+	defer sub.Unsubscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var clients []*Client
+	stopped := make(chan error)
+	for _, worker := range []string{alice, bob} {
+		c, err := Dial(addr, worker)
+		if err != nil {
+			t.Fatalf("Dial: %v", err)
+		}
+		defer c.Close()
+		clients = append(clients, c)
+
+		go func() { stopped <- c.Mine(ctx) }()
+	}
+
+	var block *blockchain.Block
+	select {
+	case ev := <-sub.Events():
+		block = ev.Block
+	case <-time.After(30 * time.Second):
+		t.Fatal("no block mined")
+	}
+	// once the clients stopped every share they found is counted
+	cancel()
+	for range clients {
+		if err := <-stopped; !errors.Is(err, context.Canceled) {
+			t.Fatalf("Mine: %v", err)
+		}
+	}
+
+	// the pool paid the block, or the workers when they found shares before it
+	coinbase := block.Transactions[len(block.Transactions)-1]
+	total := 0
+	for _, out := range coinbase.Outputs {
+		total += out.Value
+	}
+	if total != 20 {
+		t.Errorf("coinbase pays %d, want 20", total)
+	}
+
+	accepted := 0
+	for _, c := range clients {
+		n, _ := c.Stats()
+		accepted += n
+	}
+	blocks := 0
+	for _, s := range srv.Stats() {
+		blocks += s.Blocks
+	}
+	if accepted == 0 || blocks == 0 {
+		t.Errorf("clients had %d shares accepted and found %d blocks", accepted, blocks)
+	}
+}
@@ -52,11 +52,11 @@ func Base58Encode(input []byte) []byte {
 	return []byte(encode)
 }
 
-func Base58Decode(input []byte) []byte {
+func Base58Decode(input []byte) ([]byte, error) {
 	decode, err := base58.Decode(string(input))
 	if err != nil {
-		panic(fmt.Errorf("failed to decode base58, err: %w", err))
+		return nil, fmt.Errorf("failed to decode base58, err: %w", err)
 	}
 
-	return decode
+	return decode, nil
 }
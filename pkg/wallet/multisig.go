@@ -0,0 +1,108 @@
+package wallet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"blockchain/pkg/crypto"
+)
+
+const (
+	maxMultisigKeys = 16
+	maxKeyLength    = 255
+)
+
+// MultisigScript is the redeem script of an M-of-N multisig output.
+// Outputs only commit to the hash of the script, spenders reveal it.
+type MultisigScript struct {
+	Required int
+	PubKeys  [][]byte
+}
+
+func NewMultisigScript(required int, pubKeys [][]byte) (*MultisigScript, error) {
+	if len(pubKeys) == 0 || len(pubKeys) > maxMultisigKeys {
+		return nil, fmt.Errorf("invalid number of public keys: %d", len(pubKeys))
+	}
+	if required <= 0 || required > len(pubKeys) {
+		return nil, fmt.Errorf("invalid number of required signatures: %d of %d", required, len(pubKeys))
+	}
+
+	for i := range pubKeys {
+		if len(pubKeys[i]) == 0 || len(pubKeys[i]) > maxKeyLength {
+			return nil, fmt.Errorf("invalid public key length: %d", len(pubKeys[i]))
+		}
+		if _, err := crypto.ParsePublicKey(pubKeys[i]); err != nil {
+			return nil, fmt.Errorf("invalid public key %x: %w", pubKeys[i], err)
+		}
+		for j := i + 1; j < len(pubKeys); j++ {
+			if bytes.Equal(pubKeys[i], pubKeys[j]) {
+				return nil, errors.New("duplicate public key in multisig script")
+			}
+		}
+	}
+
+	return &MultisigScript{Required: required, PubKeys: pubKeys}, nil
+}
+
+// ParseMultisigScript decodes and validates a serialized redeem script
+func ParseMultisigScript(data []byte) (*MultisigScript, error) {
+	if len(data) < 2 {
+		return nil, errors.New("multisig script is too short")
+	}
+
+	required, count := int(data[0]), int(data[1])
+	data = data[2:]
+
+	pubKeys := make([][]byte, 0, count)
+	for i := 0; i < count; i++ {
+		if len(data) == 0 || len(data) < int(data[0])+1 {
+			return nil, errors.New("multisig script is truncated")
+		}
+		keyLen := int(data[0])
+		pubKeys = append(pubKeys, data[1:keyLen+1])
+		data = data[keyLen+1:]
+	}
+	if len(data) != 0 {
+		return nil, errors.New("multisig script has trailing data")
+	}
+
+	return NewMultisigScript(required, pubKeys)
+}
+
+// Serialize encodes the script as required, key count and the length prefixed keys.
+// The encoding is stable, the multisig address commits to its hash.
+func (s *MultisigScript) Serialize() []byte {
+	data := []byte{byte(s.Required), byte(len(s.PubKeys))}
+	for _, key := range s.PubKeys {
+		data = append(data, byte(len(key)))
+		data = append(data, key...)
+	}
+
+	return data
+}
+
+// Hash returns the hash the multisig output is locked with
+func (s *MultisigScript) Hash() []byte {
+	return crypto.HashPublicKey(s.Serialize())
+}
+
+func (s *MultisigScript) Address() string {
+	return MultisigAddress(s.Hash())
+}
+
+// MultisigAddress returns the address of the multisig script with the given hash
+func MultisigAddress(scriptHash []byte) string {
+	return string(encodeAddress(multisigVersion, scriptHash))
+}
+
+// KeyIndex returns the position of pubKey in the script, or -1
+func (s *MultisigScript) KeyIndex(pubKey []byte) int {
+	for i, key := range s.PubKeys {
+		if bytes.Equal(key, pubKey) {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -1,7 +1,7 @@
 package wallet
 
 import (
-	"crypto/ecdsa"
+	"fmt"
 	"os"
 
 	"blockchain/pkg/crypto"
@@ -9,36 +9,50 @@ import (
 )
 
 const (
-	checksumLength = 4
-	version        = byte(0x00)
+	checksumLength  = 4
+	multisigVersion = byte(0x05)
 )
 
+// addressVersions maps the key type of a wallet to the version byte of its addresses
+var addressVersions = map[crypto.KeyType]byte{
+	crypto.KeyECDSA:   0x00,
+	crypto.KeyEd25519: 0x01,
+	crypto.KeySchnorr: 0x02,
+}
+
 type Wallet struct {
-	PrivateKey *ecdsa.PrivateKey
-	PublicKey  *ecdsa.PublicKey
+	PrivateKey crypto.Signer
+	PublicKey  crypto.Verifier
 }
 
-func NewWallet() *Wallet {
-	priv, pub := crypto.NewKeyPair()
+func NewWallet(keyType crypto.KeyType, opts ...crypto.KeyOpt) (*Wallet, error) {
+	priv, err := crypto.GenerateKey(keyType, opts...)
+	if err != nil {
+		return nil, err
+	}
 
-	return &Wallet{priv, pub}
+	return &Wallet{priv, priv.Public()}, nil
 }
 
 func (w *Wallet) Address() []byte {
 	pubHashBytes := w.PublicKeyBytes()
 	pubHash := crypto.HashPublicKey(pubHashBytes)
 
-	versionedHash := append([]byte{version}, pubHash...)
+	return encodeAddress(addressVersions[w.PublicKey.Type()], pubHash)
+}
+
+// PublicKeyBytes returns the public key tagged with its key type
+func (w *Wallet) PublicKeyBytes() []byte {
+	return w.PublicKey.Bytes()
+}
+
+func encodeAddress(ver byte, hash []byte) []byte {
+	versionedHash := append([]byte{ver}, hash...)
 	checksum := crypto.Checksum(versionedHash, checksumLength)
 
 	fullHash := append(versionedHash, checksum...)
-	address := util.Base58Encode(fullHash)
 
-	return address
-}
-
-func (w *Wallet) PublicKeyBytes() []byte {
-	return append(w.PublicKey.X.Bytes(), w.PublicKey.Y.Bytes()...)
+	return util.Base58Encode(fullHash)
 }
 
 func (w *Wallet) saveToFile(address string) error {
@@ -48,13 +62,16 @@ func (w *Wallet) saveToFile(address string) error {
 		if err != nil {
 			return err
 		}
-		encodedPrivKey := crypto.X509EncodePrivate(w.PrivateKey)
+		encodedPrivKey, err := crypto.EncodePrivateKey(w.PrivateKey)
+		if err != nil {
+			return err
+		}
 
 		if err := os.WriteFile(folder+"/private.pem", encodedPrivKey, 0644); err != nil {
 			return err
 		}
 
-		encodedPubKey := crypto.X509EncodePublic(w.PublicKey)
+		encodedPubKey := crypto.EncodePublicKey(w.PublicKey)
 		if err := os.WriteFile(folder+"/public.pem", encodedPubKey, 0644); err != nil {
 			return err
 		}
@@ -70,18 +87,24 @@ func (w *Wallet) loadFromFile(address string) error {
 	}
 
 	priv, err := os.ReadFile(folder + "/private.pem")
-	privKey := crypto.X509DecodePrivate(priv)
 	if err != nil {
 		return err
 	}
 
-	pub, err := os.ReadFile(folder + "/public.pem")
-	pubKey := crypto.X509DecodePublic(pub)
+	privKey, err := crypto.DecodePrivateKey(priv)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to load wallet %s: %w", address, err)
+	}
+
+	// ECDSA wallets created before key types are addressed by the hash of
+	// the untagged key, which their inputs must keep using
+	if key, ok := privKey.(*crypto.ECDSAPrivateKey); ok {
+		legacy := &crypto.ECDSAPublicKey{Key: &key.Key.PublicKey, Legacy: true}
+		key.Legacy = address == string(encodeAddress(addressVersions[crypto.KeyECDSA], crypto.HashPublicKey(legacy.Bytes())))
 	}
 
-	w.PrivateKey, w.PublicKey = privKey, pubKey
+	// the public key is derived, public.pem is only kept for the user
+	w.PrivateKey, w.PublicKey = privKey, privKey.Public()
 
 	return nil
 }
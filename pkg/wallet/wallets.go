@@ -3,6 +3,7 @@ package wallet
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"os"
 	"sync"
 
@@ -28,8 +29,11 @@ func init() {
 	})
 }
 
-func CreateWallet() (string, error) {
-	wallet := NewWallet()
+func CreateWallet(keyType crypto.KeyType, opts ...crypto.KeyOpt) (string, error) {
+	wallet, err := NewWallet(keyType, opts...)
+	if err != nil {
+		return "", err
+	}
 
 	address := string(wallet.Address())
 	if err := wallet.saveToFile(address); err != nil {
@@ -53,9 +57,46 @@ func GetWallet(address string) (*Wallet, error) {
 }
 
 func PubKeyHashFromAddress(address string) ([]byte, error) {
-	pubKeyHash := util.Base58Decode([]byte(address))
-	if len(pubKeyHash) < checksumLength {
-		return nil, errors.New("invalid address")
+	_, pubKeyHash, err := DecodeAddress(address)
+
+	return pubKeyHash, err
+}
+
+// AddressFromPubKeyHash returns the address of the local wallet owning pubKeyHash.
+// The address version depends on the key type, so it can't be derived from the hash alone.
+func AddressFromPubKeyHash(pubKeyHash []byte) (string, error) {
+	for _, address := range GetAllAddresses() {
+		ver, hash, err := DecodeAddress(address)
+		if err != nil || ver == multisigVersion || !bytes.Equal(hash, pubKeyHash) {
+			continue
+		}
+
+		return address, nil
+	}
+
+	return "", fmt.Errorf("no wallet found for public key hash %x", pubKeyHash)
+}
+
+// FindSigner returns the private key of the local wallet owning pubKeyHash
+func FindSigner(pubKeyHash []byte) (crypto.Signer, error) {
+	address, err := AddressFromPubKeyHash(pubKeyHash)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := GetWallet(address)
+	if err != nil {
+		return nil, err
+	}
+
+	return w.PrivateKey, nil
+}
+
+// DecodeAddress returns the version byte and the hash encoded in address
+func DecodeAddress(address string) (byte, []byte, error) {
+	pubKeyHash, err := util.Base58Decode([]byte(address))
+	if err != nil || len(pubKeyHash) < checksumLength+1 {
+		return 0, nil, errors.New("invalid address")
 	}
 
 	actualChecksum := pubKeyHash[len(pubKeyHash)-checksumLength:]
@@ -63,10 +104,17 @@ func PubKeyHashFromAddress(address string) ([]byte, error) {
 	versionedPubKeyHash := pubKeyHash[:len(pubKeyHash)-checksumLength]
 	targetChecksum := crypto.Checksum(versionedPubKeyHash, checksumLength)
 	if !bytes.Equal(actualChecksum, targetChecksum) {
-		return nil, errors.New("invalid address")
+		return 0, nil, errors.New("invalid address")
 	}
 
-	return pubKeyHash[1 : len(pubKeyHash)-checksumLength], nil
+	return pubKeyHash[0], pubKeyHash[1 : len(pubKeyHash)-checksumLength], nil
+}
+
+// IsMultisigAddress reports whether address locks funds to a multisig script
+func IsMultisigAddress(address string) bool {
+	ver, _, err := DecodeAddress(address)
+
+	return err == nil && ver == multisigVersion
 }
 
 func GetAllAddresses() []string {